@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/prometheus/exporter-toolkit/web"
+	"go.yaml.in/yaml/v2"
+
+	"github.com/xperimental/nextcloud-exporter/internal/config"
+)
+
+// checkConfig writes the effective configuration with secrets redacted to out and checks that it is valid.
+// Besides the validation of the options, this also checks the web configuration file and the files used for TLS.
+func checkConfig(cfg config.Config, out io.Writer) error {
+	data, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		return fmt.Errorf("can not show configuration: %w", err)
+	}
+
+	if _, err := out.Write(data); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.WebConfigFile != "" {
+		if err := web.Validate(cfg.WebConfigFile); err != nil {
+			return fmt.Errorf("invalid web configuration file: %w", err)
+		}
+	}
+
+	if cfg.ServerURL != "" {
+		if _, err := newTransport(cfg.TLSOptions(), cfg.TransportOptions()); err != nil {
+			return fmt.Errorf("can not create transport: %w", err)
+		}
+	}
+
+	for name, module := range cfg.Modules {
+		if _, err := newTransport(module.TLSOptions(), module.TransportOptions()); err != nil {
+			return fmt.Errorf("can not create transport for target %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
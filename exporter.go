@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/xperimental/nextcloud-exporter/internal/client"
+	"github.com/xperimental/nextcloud-exporter/internal/config"
+	"github.com/xperimental/nextcloud-exporter/internal/metrics"
+	"github.com/xperimental/nextcloud-exporter/internal/probe"
+	"github.com/xperimental/nextcloud-exporter/internal/tlsconfig"
+	"github.com/xperimental/nextcloud-exporter/internal/transport"
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+// exporter contains the handlers created from one version of the configuration.
+type exporter struct {
+	metricsHandler http.Handler
+	probeHandler   http.Handler
+	readyHandler   http.Handler
+	landingPage    http.Handler
+
+	// collector reads the information of the server, if one is configured.
+	collector metrics.ContextCollector
+	// stop ends the background polling, if enabled.
+	stop context.CancelFunc
+}
+
+// newExporter creates the handlers for cfg. lastSuccess is the last successful contact with the server before the
+// configuration was reloaded, so that the exporter stays ready.
+func newExporter(cfg config.Config, userAgent string, lastSuccess time.Time) (*exporter, error) {
+	retry := client.RetryOptions{
+		Retries: cfg.Retries,
+		Backoff: cfg.RetryBackoff,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := &exporter{
+		metricsHandler: promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{}),
+		stop:           cancel,
+	}
+
+	var collector metrics.ContextCollector
+	if cfg.ServerURL != "" {
+		if cfg.AuthToken == "" {
+			log.Infof("Nextcloud server: %s User: %s", cfg.ServerURL, cfg.Username)
+		} else {
+			log.Infof("Nextcloud server: %s Authentication using token.", cfg.ServerURL)
+		}
+
+		if cfg.TLSSkipVerify {
+			log.Warn("HTTPS certificate verification is disabled.")
+		}
+
+		httpTransport, err := newTransport(cfg.TLSOptions(), cfg.TransportOptions())
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("can not create transport: %w", err)
+		}
+
+		infoURL := serverinfo.InfoURL(cfg.ServerURL, !cfg.Info.Apps, !cfg.Info.Update)
+		clientMetrics := client.NewMetrics()
+		credentials := client.FileCredentials(log, cfg.Password, cfg.PasswordFile, cfg.AuthToken, cfg.AuthTokenFile, clientMetrics)
+		infoClient := client.New(infoURL, cfg.Username, credentials, cfg.Timeout, userAgent, httpTransport, retry, clientMetrics)
+		if cfg.CircuitBreaker.Threshold > 0 {
+			infoClient = client.NewCircuitBreaker(cfg.CircuitBreaker.Threshold, cfg.CircuitBreaker.Cooldown, clientMetrics).Wrap(infoClient)
+		}
+
+		opts := metrics.Options{
+			InfoClient:          infoClient,
+			AppsMetrics:         cfg.Info.Apps,
+			UpdateMetrics:       cfg.Info.Update,
+			UpDuringMaintenance: cfg.UpDuringMaintenance,
+		}
+
+		if cfg.Info.AppVersions {
+			opts.AppVersionClient = client.NewAppVersion(cfg.ServerURL, cfg.Username, credentials, cfg.Timeout, userAgent, httpTransport)
+		}
+
+		if cfg.Status.Enabled {
+			opts.StatusClient = client.CacheStatus(client.NewStatus(cfg.ServerURL, cfg.Timeout, userAgent, httpTransport), cfg.Status.Interval)
+		}
+
+		if cfg.PollInterval > 0 {
+			log.Infof("Polling server every %s, maximum age %s.", cfg.PollInterval, cfg.PollMaxAge)
+			collector = metrics.NewPollingCollector(ctx, log, opts, cfg.PollInterval, cfg.PollMaxAge)
+		} else {
+			collector = metrics.NewCollector(log, opts)
+		}
+
+		// the client metrics are part of the exporter, so that they are replaced together with the client
+		registry := prometheus.NewRegistry()
+		if err := registry.Register(clientMetrics); err != nil {
+			cancel()
+			return nil, fmt.Errorf("can not register client metrics: %w", err)
+		}
+
+		result.metricsHandler = metrics.NewHandler(log, prometheus.Gatherers{prometheus.DefaultGatherer, registry}, collector)
+	}
+
+	targets := make(map[string]probe.Target, len(cfg.Modules))
+	for name, module := range cfg.Modules {
+		log.Infof("Probe target %q: %s", name, module.ServerURL)
+		if module.TLSSkipVerify {
+			log.Warnf("HTTPS certificate verification is disabled for target %q.", name)
+		}
+
+		httpTransport, err := newTransport(module.TLSOptions(), module.TransportOptions())
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("can not create transport for target %q: %w", name, err)
+		}
+
+		infoURL := serverinfo.InfoURL(module.ServerURL, !module.Info.Apps, !module.Info.Update)
+		clientMetrics := client.NewMetrics()
+		credentials := client.FileCredentials(log.WithField("target", name), module.Password, module.PasswordFile, module.AuthToken, module.AuthTokenFile, clientMetrics)
+		infoClient := client.New(infoURL, module.Username, credentials, module.Timeout, userAgent, httpTransport, retry, clientMetrics)
+		if cfg.CircuitBreaker.Threshold > 0 {
+			infoClient = client.NewCircuitBreaker(cfg.CircuitBreaker.Threshold, cfg.CircuitBreaker.Cooldown, clientMetrics).Wrap(infoClient)
+		}
+
+		target := probe.Target{
+			Options: metrics.Options{
+				InfoClient:          infoClient,
+				AppsMetrics:         module.Info.Apps,
+				UpdateMetrics:       module.Info.Update,
+				UpDuringMaintenance: cfg.UpDuringMaintenance,
+			},
+			ClientMetrics: clientMetrics,
+		}
+
+		if module.Info.AppVersions {
+			target.AppVersionClient = client.NewAppVersion(module.ServerURL, module.Username, credentials, module.Timeout, userAgent, httpTransport)
+		}
+
+		if cfg.Status.Enabled {
+			target.StatusClient = client.CacheStatus(client.NewStatus(module.ServerURL, module.Timeout, userAgent, httpTransport), cfg.Status.Interval)
+		}
+
+		targets[name] = target
+	}
+
+	landingPage, err := newLandingPage(cfg)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("can not create landing page: %w", err)
+	}
+
+	result.probeHandler = probe.NewHandler(log, targets)
+	if collector != nil {
+		collector = metrics.WithPreviousSuccess(collector, lastSuccess)
+	}
+
+	result.readyHandler = metrics.NewReadyHandler(collector, cfg.ReadyMaxAge)
+	result.collector = collector
+	result.landingPage = landingPage
+
+	return result, nil
+}
+
+// lastSuccess returns the time of the last successful contact with the server. It is zero if no server is configured.
+func (e *exporter) lastSuccess() time.Time {
+	if e.collector == nil {
+		return time.Time{}
+	}
+
+	return e.collector.LastSuccess()
+}
+
+func newTransport(tlsOptions tlsconfig.Options, options transport.Options) (http.RoundTripper, error) {
+	tlsConfig, err := tlsconfig.New(tlsOptions)
+	if err != nil {
+		return nil, fmt.Errorf("can not create TLS configuration: %w", err)
+	}
+	options.TLSConfig = tlsConfig
+
+	return transport.New(options)
+}
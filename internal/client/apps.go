@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+const (
+	ocsAPIRequestHeader = "OCS-APIRequest"
+	ocsStatusOK         = 100
+)
+
+// AppVersionClient returns the installed version of an app.
+type AppVersionClient interface {
+	AppVersion(ctx context.Context, appID string) (string, error)
+}
+
+// AppVersionClientFunc is an adapter to allow the use of ordinary functions as AppVersionClient.
+type AppVersionClientFunc func(ctx context.Context, appID string) (string, error)
+
+// AppVersion calls f(ctx, appID).
+func (f AppVersionClientFunc) AppVersion(ctx context.Context, appID string) (string, error) {
+	return f(ctx, appID)
+}
+
+// NewAppVersion creates a client which reads the installed version of apps using the OCS apps API.
+// The API needs the credentials of an admin user, it can not be used with token authentication.
+func NewAppVersion(serverURL, username string, credentials Credentials, timeout time.Duration, userAgent string, transport http.RoundTripper) AppVersionClient {
+	client := newHTTPClient(timeout, transport)
+
+	return AppVersionClientFunc(func(ctx context.Context, appID string) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverinfo.AppInfoURL(serverURL, appID), nil)
+		if err != nil {
+			return "", err
+		}
+
+		password, _ := credentials.Credentials()
+		req.SetBasicAuth(username, password)
+		req.Header.Set(ocsAPIRequestHeader, "true")
+		req.Header.Set("User-Agent", userAgent)
+
+		res, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+
+		if err := checkStatus(res); err != nil {
+			return "", err
+		}
+
+		var result struct {
+			OCS struct {
+				Meta serverinfo.Meta `json:"meta"`
+				Data json.RawMessage `json:"data"`
+			} `json:"ocs"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+			return "", fmt.Errorf("can not parse app info: %w", err)
+		}
+
+		if result.OCS.Meta.StatusCode != ocsStatusOK {
+			return "", fmt.Errorf("unexpected OCS status %d: %s", result.OCS.Meta.StatusCode, result.OCS.Meta.Message)
+		}
+
+		var appInfo struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(result.OCS.Data, &appInfo); err != nil {
+			return "", fmt.Errorf("can not parse app info: %w", err)
+		}
+
+		return appInfo.Version, nil
+	})
+}
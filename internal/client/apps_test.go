@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xperimental/nextcloud-exporter/internal/testutil"
+)
+
+func TestAppVersionClient(t *testing.T) {
+	wantUsername := "test-user"
+	wantPassword := "test-password"
+
+	tt := []struct {
+		desc        string
+		handler     func(t *testing.T) http.Handler
+		wantVersion string
+		wantErr     error
+	}{
+		{
+			desc: "success",
+			handler: func(t *testing.T) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					user, password, ok := req.BasicAuth()
+					if !ok || user != wantUsername || password != wantPassword {
+						t.Errorf("got credentials %q/%q, want %q/%q", user, password, wantUsername, wantPassword)
+					}
+
+					if req.Header.Get(ocsAPIRequestHeader) != "true" {
+						t.Errorf("missing %s header", ocsAPIRequestHeader)
+					}
+
+					wantPath := "/ocs/v1.php/cloud/apps/calendar"
+					if req.URL.Path != wantPath {
+						t.Errorf("got path %q, want %q", req.URL.Path, wantPath)
+					}
+
+					fmt.Fprintln(w, `{"ocs": {"meta": {"status": "ok", "statuscode": 100}, "data": {"id": "calendar", "version": "4.4.0"}}}`)
+				})
+			},
+			wantVersion: "4.4.0",
+		},
+		{
+			desc: "ocs error",
+			handler: func(t *testing.T) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					fmt.Fprintln(w, `{"ocs": {"meta": {"status": "failure", "statuscode": 998, "message": "The requested app was not found"}, "data": []}}`)
+				})
+			},
+			wantErr: errors.New("unexpected OCS status 998: The requested app was not found"),
+		},
+		{
+			desc: "auth error",
+			handler: func(t *testing.T) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					w.WriteHeader(http.StatusUnauthorized)
+				})
+			},
+			wantErr: ErrNotAuthorized,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			s := httptest.NewServer(tc.handler(t))
+			defer s.Close()
+
+			client := NewAppVersion(s.URL, wantUsername, StaticCredentials(wantPassword, ""), time.Second, "test-ua", nil)
+
+			version, err := client.AppVersion(context.Background(), "calendar")
+
+			if !testutil.EqualErrorMessage(err, tc.wantErr) {
+				t.Errorf("got error %q, want %q", err, tc.wantErr)
+			}
+
+			if version != tc.wantVersion {
+				t.Errorf("got version %q, want %q", version, tc.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+// ErrCircuitOpen is returned, together with the error which opened the circuit, while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitOpenError is returned instead of doing a request while the circuit is open.
+type circuitOpenError struct {
+	err error
+}
+
+func (e *circuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrCircuitOpen, e.err)
+}
+
+func (e *circuitOpenError) Unwrap() []error {
+	return []error{ErrCircuitOpen, e.err}
+}
+
+// CircuitBreaker stops requests to a Nextcloud instance after consecutive failures caused by wrong credentials,
+// rate-limiting or maintenance mode. After the cool-down a single request is let through to check if the server recovered.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	metrics   *Metrics
+	nowFunc   func() time.Time
+
+	lock     sync.Mutex
+	state    circuitState
+	cause    string
+	failures int
+	lastErr  error
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker creates a circuit breaker which opens after threshold consecutive failures with the same cause
+// and stays open for cooldown. If metrics is not nil, the state of the circuit is exported.
+func NewCircuitBreaker(threshold int, cooldown time.Duration, metrics *Metrics) *CircuitBreaker {
+	metrics.enableCircuitState()
+
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		metrics:   metrics,
+		nowFunc:   time.Now,
+	}
+}
+
+// Wrap returns an InfoClient which only calls client if the circuit is not open.
+func (b *CircuitBreaker) Wrap(client InfoClient) InfoClient {
+	return InfoClientFunc(func(ctx context.Context) (*serverinfo.ServerInfo, error) {
+		if err := b.allow(); err != nil {
+			return nil, err
+		}
+
+		info, err := client.Info(ctx)
+		b.record(err)
+		return info, err
+	})
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.nowFunc().Sub(b.openedAt) < b.cooldown {
+			return &circuitOpenError{err: b.lastErr}
+		}
+
+		b.setState(circuitHalfOpen)
+		b.probing = true
+		return nil
+	case circuitHalfOpen:
+		if b.probing {
+			return &circuitOpenError{err: b.lastErr}
+		}
+
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if errors.Is(err, context.Canceled) {
+		// the request was aborted by the caller, so it does not tell anything about the server
+		b.probing = false
+		return
+	}
+
+	if b.state == circuitHalfOpen {
+		b.probing = false
+		if err != nil {
+			b.open(err)
+			return
+		}
+
+		b.reset()
+		return
+	}
+
+	cause, ok := circuitCause(err)
+	if !ok {
+		b.reset()
+		return
+	}
+
+	if cause != b.cause {
+		b.cause = cause
+		b.failures = 0
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.open(err)
+	}
+}
+
+func (b *CircuitBreaker) open(err error) {
+	b.lastErr = err
+	b.openedAt = b.nowFunc()
+	b.setState(circuitOpen)
+}
+
+func (b *CircuitBreaker) reset() {
+	b.cause = ""
+	b.failures = 0
+	b.lastErr = nil
+	b.setState(circuitClosed)
+}
+
+func (b *CircuitBreaker) setState(state circuitState) {
+	b.state = state
+	b.metrics.setCircuitState(state)
+}
+
+// circuitCause returns the cause of errors which count towards opening the circuit.
+func circuitCause(err error) (string, bool) {
+	switch {
+	case err == nil:
+		return "", false
+	case errors.Is(err, ErrNotAuthorized):
+		return "auth", true
+	case errors.Is(err, ErrRatelimit):
+		return "ratelimit", true
+	case errors.Is(err, ErrMaintenanceMode):
+		return "maintenance", true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	internaltestutil "github.com/xperimental/nextcloud-exporter/internal/testutil"
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	errNetwork := errors.New("connection refused")
+	errOpenMaintenance := errors.New("circuit breaker open: maintenance mode")
+
+	type step struct {
+		advance    time.Duration
+		clientErr  error
+		wantCalled bool
+		wantErr    error
+		wantState  circuitState
+	}
+
+	tt := []struct {
+		desc  string
+		steps []step
+	}{
+		{
+			desc: "open after threshold",
+			steps: []step{
+				{clientErr: ErrMaintenanceMode, wantCalled: true, wantErr: ErrMaintenanceMode, wantState: circuitClosed},
+				{clientErr: ErrMaintenanceMode, wantCalled: true, wantErr: ErrMaintenanceMode, wantState: circuitOpen},
+				{wantCalled: false, wantErr: errOpenMaintenance, wantState: circuitOpen},
+			},
+		},
+		{
+			desc: "different causes",
+			steps: []step{
+				{clientErr: ErrMaintenanceMode, wantCalled: true, wantErr: ErrMaintenanceMode, wantState: circuitClosed},
+				{clientErr: ErrRatelimit, wantCalled: true, wantErr: ErrRatelimit, wantState: circuitClosed},
+				{clientErr: errNetwork, wantCalled: true, wantErr: errNetwork, wantState: circuitClosed},
+				{clientErr: ErrRatelimit, wantCalled: true, wantErr: ErrRatelimit, wantState: circuitClosed},
+			},
+		},
+		{
+			desc: "close after successful probe",
+			steps: []step{
+				{clientErr: ErrNotAuthorized, wantCalled: true, wantErr: ErrNotAuthorized, wantState: circuitClosed},
+				{clientErr: ErrNotAuthorized, wantCalled: true, wantErr: ErrNotAuthorized, wantState: circuitOpen},
+				{advance: time.Minute, wantCalled: true, wantState: circuitClosed},
+				{wantCalled: true, wantState: circuitClosed},
+			},
+		},
+		{
+			desc: "reopen after failed probe",
+			steps: []step{
+				{clientErr: ErrMaintenanceMode, wantCalled: true, wantErr: ErrMaintenanceMode, wantState: circuitClosed},
+				{clientErr: ErrMaintenanceMode, wantCalled: true, wantErr: ErrMaintenanceMode, wantState: circuitOpen},
+				{advance: time.Minute, clientErr: ErrMaintenanceMode, wantCalled: true, wantErr: ErrMaintenanceMode, wantState: circuitOpen},
+				{advance: 30 * time.Second, wantCalled: false, wantErr: errOpenMaintenance, wantState: circuitOpen},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+			metrics := NewMetrics()
+			breaker := NewCircuitBreaker(2, time.Minute, metrics)
+			breaker.nowFunc = func() time.Time { return now }
+
+			var clientErr error
+			called := false
+			client := breaker.Wrap(InfoClientFunc(func(_ context.Context) (*serverinfo.ServerInfo, error) {
+				called = true
+				if clientErr != nil {
+					return nil, clientErr
+				}
+
+				return &serverinfo.ServerInfo{}, nil
+			}))
+
+			for i, s := range tc.steps {
+				now = now.Add(s.advance)
+				clientErr = s.clientErr
+				called = false
+
+				_, err := client.Info(context.Background())
+				if !internaltestutil.EqualErrorMessage(err, s.wantErr) {
+					t.Errorf("step %d: got error %q, want %q", i, err, s.wantErr)
+				}
+
+				if called != s.wantCalled {
+					t.Errorf("step %d: got called %v, want %v", i, called, s.wantCalled)
+				}
+
+				if state := testutil.ToFloat64(metrics.circuitState); state != float64(s.wantState) {
+					t.Errorf("step %d: got state %f, want %d", i, state, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitOpenError(t *testing.T) {
+	err := error(&circuitOpenError{err: ErrRatelimit})
+
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Error("error is not ErrCircuitOpen")
+	}
+
+	if !errors.Is(err, ErrRatelimit) {
+		t.Error("error is not ErrRatelimit")
+	}
+}
@@ -1,10 +1,13 @@
 package client
 
 import (
-	"crypto/tls"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptrace"
 	"time"
 
 	"github.com/xperimental/nextcloud-exporter/serverinfo"
@@ -20,63 +23,121 @@ var (
 	ErrRatelimit       = errors.New("too many requests")
 	ErrUnavailable     = errors.New("service unavailable")
 	ErrMaintenanceMode = errors.New("maintenance mode")
+	ErrGateway         = errors.New("gateway error")
 )
 
-type InfoClient func() (*serverinfo.ServerInfo, error)
-
-func New(infoURL, username, password, authToken string, timeout time.Duration, userAgent string, tlsSkipVerify bool) InfoClient {
-	client := &http.Client{
-		Timeout: timeout,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				// disable TLS certification verification, if desired
-				InsecureSkipVerify: tlsSkipVerify,
-			},
-		},
-	}
+// InfoClient reads the serverinfo document of a Nextcloud instance.
+type InfoClient interface {
+	Info(ctx context.Context) (*serverinfo.ServerInfo, error)
+}
 
-	return func() (*serverinfo.ServerInfo, error) {
-		req, err := http.NewRequest(http.MethodGet, infoURL, nil)
-		if err != nil {
-			return nil, err
-		}
+// InfoClientFunc is an adapter to allow the use of ordinary functions as InfoClient.
+type InfoClientFunc func(ctx context.Context) (*serverinfo.ServerInfo, error)
 
-		if authToken == "" {
-			req.SetBasicAuth(username, password)
-		} else {
-			req.Header.Set(nextcloudTokenHeader, authToken)
-		}
+// Info calls f(ctx).
+func (f InfoClientFunc) Info(ctx context.Context) (*serverinfo.ServerInfo, error) {
+	return f(ctx)
+}
 
-		req.Header.Set("User-Agent", userAgent)
+// New creates an InfoClient reading the server information from infoURL.
+// Failed requests are retried according to retry, as long as neither the timeout nor the deadline of the context is exceeded.
+// A timeout of zero disables the timeout.
+// If transport is nil, http.DefaultTransport is used. If metrics is not nil, the duration, size and status code of the requests are recorded in it.
+func New(infoURL, username string, credentials Credentials, timeout time.Duration, userAgent string, transport http.RoundTripper, retry RetryOptions, metrics *Metrics) InfoClient {
+	client := newHTTPClient(timeout, transport)
 
-		res, err := client.Do(req)
-		if err != nil {
-			return nil, err
-		}
-		defer res.Body.Close()
-
-		switch res.StatusCode {
-		case http.StatusOK:
-			break
-		case http.StatusUnauthorized:
-			return nil, ErrNotAuthorized
-		case http.StatusTooManyRequests:
-			return nil, ErrRatelimit
-		case http.StatusServiceUnavailable:
-			if res.Header.Get(maintenanceModeHeader) != "" {
-				return nil, ErrMaintenanceMode
-			}
-
-			return nil, ErrUnavailable
-		default:
-			return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	return InfoClientFunc(func(ctx context.Context) (*serverinfo.ServerInfo, error) {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
 		}
 
-		status, err := serverinfo.ParseJSON(res.Body)
+		var status *serverinfo.ServerInfo
+		err := retry.do(ctx, metrics, func(ctx context.Context) error {
+			var err error
+			password, authToken := credentials.Credentials()
+			status, err = getInfo(ctx, client, infoURL, username, password, authToken, userAgent, metrics)
+			return err
+		})
 		if err != nil {
-			return nil, fmt.Errorf("can not parse server info: %w", err)
+			return nil, err
 		}
 
 		return status, nil
+	})
+}
+
+func getInfo(ctx context.Context, client *http.Client, infoURL, username, password, authToken, userAgent string, metrics *Metrics) (*serverinfo.ServerInfo, error) {
+	trace := newRequestTrace()
+	defer trace.observe(metrics)
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace.clientTrace()), http.MethodGet, infoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if authToken == "" {
+		req.SetBasicAuth(username, password)
+	} else {
+		req.Header.Set(nextcloudTokenHeader, authToken)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	metrics.observeResponse(res.StatusCode)
+
+	if err := checkStatus(res); err != nil {
+		return nil, withRetryAfter(err, res)
+	}
+
+	bodyStart := time.Now()
+	body, err := io.ReadAll(res.Body)
+	metrics.observeSize(len(body))
+	if err != nil {
+		return nil, fmt.Errorf("can not read server info: %w", err)
+	}
+	metrics.observeDuration(phaseBody, time.Since(bodyStart))
+
+	decodeStart := time.Now()
+	status, err := serverinfo.ParseJSON(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("can not parse server info: %w", err)
+	}
+	metrics.observeDuration(phaseDecode, time.Since(decodeStart))
+
+	return status, nil
+}
+
+func newHTTPClient(timeout time.Duration, transport http.RoundTripper) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+func checkStatus(res *http.Response) error {
+	switch res.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return ErrNotAuthorized
+	case http.StatusTooManyRequests:
+		return ErrRatelimit
+	case http.StatusServiceUnavailable:
+		if res.Header.Get(maintenanceModeHeader) != "" {
+			return ErrMaintenanceMode
+		}
+
+		return ErrUnavailable
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return fmt.Errorf("%w: status code %d", ErrGateway, res.StatusCode)
+	default:
+		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
 	}
 }
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -164,9 +165,9 @@ func TestClient(t *testing.T) {
 			s := httptest.NewServer(tc.handler(t))
 			defer s.Close()
 
-			client := New(s.URL, wantUsername, tc.password, tc.token, time.Second, wantUserAgent, false)
+			client := New(s.URL, wantUsername, StaticCredentials(tc.password, tc.token), time.Second, wantUserAgent, nil, RetryOptions{}, nil)
 
-			info, err := client()
+			info, err := client.Info(context.Background())
 
 			if !testutil.EqualErrorMessage(err, tc.wantErr) {
 				t.Errorf("got error %q, want %q", err, tc.wantErr)
@@ -182,3 +183,48 @@ func TestClient(t *testing.T) {
 		})
 	}
 }
+
+func TestClientCancel(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-release:
+		}
+	}))
+	defer s.Close()
+
+	client := New(s.URL, "", StaticCredentials("", "token"), time.Minute, "", nil, RetryOptions{Retries: 3}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Info(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %q, want %q", err, context.DeadlineExceeded)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %s after context was done", elapsed)
+	}
+}
+
+func TestClientNoTimeout(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintln(w, "{}")
+	}))
+	defer s.Close()
+
+	client := New(s.URL, "", StaticCredentials("", "token"), 0, "", nil, RetryOptions{}, nil)
+
+	if _, err := client.Info(context.Background()); err != nil {
+		t.Errorf("got error %q, want nil", err)
+	}
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Credentials provides the password and token used for authenticating with the Nextcloud server.
+// They are requested for every request, so that changed credentials are used without creating a new client.
+type Credentials interface {
+	Credentials() (password, authToken string)
+}
+
+// CredentialsFunc is an adapter to allow the use of ordinary functions as Credentials.
+type CredentialsFunc func() (password, authToken string)
+
+// Credentials calls f().
+func (f CredentialsFunc) Credentials() (password, authToken string) {
+	return f()
+}
+
+// StaticCredentials returns Credentials which do not change.
+func StaticCredentials(password, authToken string) Credentials {
+	return CredentialsFunc(func() (string, string) {
+		return password, authToken
+	})
+}
+
+// FileCredentials returns Credentials which read the password and token again when the file containing them changes.
+// An empty file name means the value is not read from a file. If a file can not be read or is empty, the previous value is kept.
+// Every time changed credentials are read, the time is recorded in metrics, if it is not nil.
+func FileCredentials(log logrus.FieldLogger, password, passwordFile, authToken, authTokenFile string, metrics *Metrics) Credentials {
+	c := &fileCredentials{
+		log:     log,
+		metrics: metrics,
+		password: secretFile{
+			name:     "password",
+			fileName: passwordFile,
+			value:    password,
+		},
+		authToken: secretFile{
+			name:     "token",
+			fileName: authTokenFile,
+			value:    authToken,
+		},
+	}
+
+	if passwordFile != "" || authTokenFile != "" {
+		metrics.enableCredentialsReload()
+		metrics.observeCredentialsReload(time.Now())
+	}
+
+	return c
+}
+
+type fileCredentials struct {
+	log     logrus.FieldLogger
+	metrics *Metrics
+
+	lock      sync.Mutex
+	password  secretFile
+	authToken secretFile
+}
+
+func (c *fileCredentials) Credentials() (string, string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	passwordChanged := c.password.update(c.log)
+	authTokenChanged := c.authToken.update(c.log)
+	if passwordChanged || authTokenChanged {
+		c.metrics.observeCredentialsReload(time.Now())
+	}
+
+	return c.password.value, c.authToken.value
+}
+
+// secretFile contains a value which is read from a file.
+type secretFile struct {
+	name     string
+	fileName string
+	value    string
+	modTime  time.Time
+}
+
+// update reads the file again, if it has been modified since it was last read. It returns true, if the value changed.
+// The value itself is never logged.
+func (s *secretFile) update(log logrus.FieldLogger) bool {
+	if s.fileName == "" {
+		return false
+	}
+
+	info, err := os.Stat(s.fileName)
+	if err != nil {
+		log.Warnf("Can not read %s file, keeping previous value: %s", s.name, err)
+		return false
+	}
+
+	if info.ModTime().Equal(s.modTime) {
+		return false
+	}
+
+	data, err := os.ReadFile(s.fileName)
+	if err != nil {
+		log.Warnf("Can not read %s file, keeping previous value: %s", s.name, err)
+		return false
+	}
+
+	value := strings.TrimSuffix(string(data), "\n")
+	if value == "" {
+		// files are sometimes truncated before the new content is written
+		log.Warnf("The %s file is empty, keeping previous value.", s.name)
+		return false
+	}
+	s.modTime = info.ModTime()
+
+	if value == s.value {
+		return false
+	}
+
+	log.Infof("The %s in %s changed.", s.name, s.fileName)
+	s.value = value
+	return true
+}
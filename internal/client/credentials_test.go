@@ -0,0 +1,100 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	start := time.Now().Add(-time.Hour)
+
+	writeFile := func(t *testing.T, content string, modTime time.Time) {
+		t.Helper()
+
+		if err := os.WriteFile(passwordFile, []byte(content), 0o600); err != nil {
+			t.Fatalf("error writing password file: %s", err)
+		}
+
+		if err := os.Chtimes(passwordFile, modTime, modTime); err != nil {
+			t.Fatalf("error setting modification time: %s", err)
+		}
+	}
+
+	writeFile(t, "initial\n", start)
+
+	log := logrus.New()
+	metrics := NewMetrics()
+	credentials := FileCredentials(log, "initial", passwordFile, "token", "", metrics)
+	reloadTime := testutil.ToFloat64(metrics.credentials)
+
+	steps := []struct {
+		desc         string
+		change       func(t *testing.T)
+		wantPassword string
+		wantReload   bool
+	}{
+		{
+			desc:         "unchanged",
+			change:       func(t *testing.T) {},
+			wantPassword: "initial",
+		},
+		{
+			desc: "changed",
+			change: func(t *testing.T) {
+				writeFile(t, "rotated\n", start.Add(time.Minute))
+			},
+			wantPassword: "rotated",
+			wantReload:   true,
+		},
+		{
+			desc: "empty",
+			change: func(t *testing.T) {
+				writeFile(t, "", start.Add(2*time.Minute))
+			},
+			wantPassword: "rotated",
+		},
+		{
+			desc: "missing",
+			change: func(t *testing.T) {
+				if err := os.Remove(passwordFile); err != nil {
+					t.Fatalf("error removing password file: %s", err)
+				}
+			},
+			wantPassword: "rotated",
+		},
+		{
+			desc: "recreated",
+			change: func(t *testing.T) {
+				writeFile(t, "second", start.Add(3*time.Minute))
+			},
+			wantPassword: "second",
+			wantReload:   true,
+		},
+	}
+
+	for _, step := range steps {
+		step.change(t)
+
+		password, authToken := credentials.Credentials()
+		if password != step.wantPassword {
+			t.Errorf("%s: got password %q, want %q", step.desc, password, step.wantPassword)
+		}
+
+		if authToken != "token" {
+			t.Errorf("%s: got token %q, want %q", step.desc, authToken, "token")
+		}
+
+		newReloadTime := testutil.ToFloat64(metrics.credentials)
+		if reloaded := newReloadTime != reloadTime; reloaded != step.wantReload {
+			t.Errorf("%s: got reload %v, want %v", step.desc, reloaded, step.wantReload)
+		}
+		reloadTime = newReloadTime
+	}
+}
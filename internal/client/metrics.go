@@ -0,0 +1,231 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricPrefix = "nextcloud_"
+
+	phaseDNS     = "dns"
+	phaseConnect = "connect"
+	phaseTLS     = "tls"
+	phaseWait    = "ttfb"
+	phaseBody    = "body"
+	phaseDecode  = "decode"
+)
+
+// Metrics contains the instrumentation of the requests done by an InfoClient.
+type Metrics struct {
+	duration     *prometheus.HistogramVec
+	responseSize prometheus.Counter
+	responses    *prometheus.CounterVec
+	retries      *prometheus.CounterVec
+	circuitState prometheus.Gauge
+	credentials  prometheus.Gauge
+
+	// circuitEnabled is set when a circuit breaker uses these metrics.
+	circuitEnabled bool
+	// credentialsEnabled is set when the credentials are read from files.
+	credentialsEnabled bool
+}
+
+var _ prometheus.Collector = &Metrics{}
+
+// NewMetrics creates the metrics used for instrumenting an InfoClient. They need to be registered separately.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    metricPrefix + "scrape_duration_seconds",
+			Help:    "Duration of requests to the serverinfo API by phase.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"phase"}),
+		responseSize: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: metricPrefix + "scrape_response_size_bytes_total",
+			Help: "Total size of the response bodies read from the serverinfo API.",
+		}),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: metricPrefix + "scrape_responses_total",
+			Help: "Number of responses received from the serverinfo API by HTTP status code.",
+		}, []string{"code"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: metricPrefix + "scrape_retries_total",
+			Help: "Number of retried requests to the serverinfo API by cause.",
+		}, []string{"cause"}),
+		circuitState: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: metricPrefix + "client_circuit_state",
+			Help: "State of the circuit breaker: 0 closed, 1 open, 2 half-open.",
+		}),
+		credentials: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: metricPrefix + "client_credentials_last_reload_timestamp_seconds",
+			Help: "Timestamp of the last time changed credentials were read from a file.",
+		}),
+	}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.duration.Describe(ch)
+	m.responseSize.Describe(ch)
+	m.responses.Describe(ch)
+	m.retries.Describe(ch)
+	m.circuitState.Describe(ch)
+	m.credentials.Describe(ch)
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.duration.Collect(ch)
+	m.responseSize.Collect(ch)
+	m.responses.Collect(ch)
+	m.retries.Collect(ch)
+	if m.circuitEnabled {
+		m.circuitState.Collect(ch)
+	}
+	if m.credentialsEnabled {
+		m.credentials.Collect(ch)
+	}
+}
+
+func (m *Metrics) observeDuration(phase string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.duration.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+func (m *Metrics) observeResponse(statusCode int) {
+	if m == nil {
+		return
+	}
+
+	m.responses.WithLabelValues(strconv.Itoa(statusCode)).Inc()
+}
+
+func (m *Metrics) observeRetry(cause string) {
+	if m == nil {
+		return
+	}
+
+	m.retries.WithLabelValues(cause).Inc()
+}
+
+func (m *Metrics) enableCircuitState() {
+	if m == nil {
+		return
+	}
+
+	m.circuitEnabled = true
+}
+
+func (m *Metrics) setCircuitState(state circuitState) {
+	if m == nil {
+		return
+	}
+
+	m.circuitState.Set(float64(state))
+}
+
+func (m *Metrics) enableCredentialsReload() {
+	if m == nil {
+		return
+	}
+
+	m.credentialsEnabled = true
+}
+
+func (m *Metrics) observeCredentialsReload(now time.Time) {
+	if m == nil {
+		return
+	}
+
+	m.credentials.Set(float64(now.UnixNano()) / 1e9)
+}
+
+func (m *Metrics) observeSize(size int) {
+	if m == nil {
+		return
+	}
+
+	m.responseSize.Add(float64(size))
+}
+
+// requestTrace collects the duration of the network phases of a single request.
+// Phases which did not happen, for example because a connection was reused, are not observed.
+type requestTrace struct {
+	lock      sync.Mutex
+	starts    map[string]time.Time
+	durations map[string]time.Duration
+}
+
+func newRequestTrace() *requestTrace {
+	return &requestTrace{
+		starts:    make(map[string]time.Time),
+		durations: make(map[string]time.Duration),
+	}
+}
+
+func (t *requestTrace) start(phase string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.starts[phase]; !ok {
+		t.starts[phase] = time.Now()
+	}
+}
+
+func (t *requestTrace) done(phase string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if start, ok := t.starts[phase]; ok {
+		t.durations[phase] = time.Since(start)
+	}
+}
+
+func (t *requestTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.start(phaseDNS)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.done(phaseDNS)
+		},
+		ConnectStart: func(string, string) {
+			t.start(phaseConnect)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.done(phaseConnect)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.start(phaseTLS)
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				t.done(phaseTLS)
+			}
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.start(phaseWait)
+		},
+		GotFirstResponseByte: func() {
+			t.done(phaseWait)
+		},
+	}
+}
+
+func (t *requestTrace) observe(m *Metrics) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for phase, duration := range t.durations {
+		m.observeDuration(phase, duration)
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	body := `{"ocs": {"meta": {"status": "OK", "statuscode": 200}}}` + "\n"
+
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer s.Close()
+
+	metrics := NewMetrics()
+	client := New(s.URL, "", StaticCredentials("", "token"), time.Second, "test-ua", &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}, RetryOptions{}, metrics)
+
+	if _, err := client.Info(context.Background()); err != nil {
+		t.Fatalf("got error %q", err)
+	}
+
+	// The server uses an IP address, so there is no DNS lookup.
+	wantPhases := []string{phaseBody, phaseConnect, phaseDecode, phaseTLS, phaseWait}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics.duration)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("got error gathering metrics: %s", err)
+	}
+
+	var phases []string
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			phases = append(phases, m.GetLabel()[0].GetValue())
+		}
+	}
+
+	if diff := cmp.Diff(phases, wantPhases); diff != "" {
+		t.Errorf("phases differ: -got+want\n%s", diff)
+	}
+
+	if size := testutil.ToFloat64(metrics.responseSize); size != float64(len(body)) {
+		t.Errorf("got size %f, want %d", size, len(body))
+	}
+
+	if responses := testutil.ToFloat64(metrics.responses.WithLabelValues("200")); responses != 1 {
+		t.Errorf("got %f responses, want 1", responses)
+	}
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	retryAfterHeader = "Retry-After"
+
+	// defaultRetryBackoff is used as delay before the first retry, if no other value is configured.
+	defaultRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = 10 * time.Second
+
+	retryCauseRatelimit   = "ratelimit"
+	retryCauseUnavailable = "unavailable"
+	retryCauseGateway     = "gateway"
+	retryCauseNetwork     = "network"
+)
+
+// RetryOptions controls how failed requests are retried.
+type RetryOptions struct {
+	// Retries is the maximum number of retries after the first attempt. Zero disables retries.
+	Retries int
+	// Backoff is the delay before the first retry. It is doubled for every further retry.
+	Backoff time.Duration
+}
+
+// retryAfterError wraps an error of a response containing a Retry-After header.
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// withRetryAfter adds the delay requested by the server to errors caused by rate-limiting or unavailability.
+func withRetryAfter(err error, res *http.Response) error {
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return err
+	}
+
+	delay, ok := parseRetryAfter(res.Header.Get(retryAfterHeader), time.Now())
+	if !ok {
+		return err
+	}
+
+	return &retryAfterError{
+		err:   err,
+		delay: delay,
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which can either contain a number of seconds or a date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	delay := date.Sub(now)
+	if delay < 0 {
+		delay = 0
+	}
+
+	return delay, true
+}
+
+// retryCause returns the cause label for errors which can be retried.
+// Errors caused by wrong credentials or maintenance mode are never retried.
+func retryCause(err error) (string, bool) {
+	switch {
+	case errors.Is(err, ErrRatelimit):
+		return retryCauseRatelimit, true
+	case errors.Is(err, ErrUnavailable):
+		return retryCauseUnavailable, true
+	case errors.Is(err, ErrGateway):
+		return retryCauseGateway, true
+	}
+
+	// other errors of the HTTP client, for example invalid certificates, are not temporary
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return retryCauseNetwork, true
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return retryCauseNetwork, true
+	}
+
+	return "", false
+}
+
+func (o RetryOptions) backoff(retry int) time.Duration {
+	delay := o.Backoff
+	if delay <= 0 {
+		delay = defaultRetryBackoff
+	}
+
+	for i := 0; i < retry && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+
+	// use a random delay between half and the full backoff
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// delay returns the delay before the next retry. The delay requested by the server is used, if it is not longer than
+// maxRetryBackoff, so that requests without a deadline are not blocked for a long time.
+func (o RetryOptions) delay(retry int, err error) time.Duration {
+	var retryAfter *retryAfterError
+	if errors.As(err, &retryAfter) {
+		return min(retryAfter.delay, maxRetryBackoff)
+	}
+
+	return o.backoff(retry)
+}
+
+// do runs attempt until it succeeds, returns an error which can not be retried or no retries are left.
+// It also stops when the next attempt would start after the deadline of the context.
+func (o RetryOptions) do(ctx context.Context, metrics *Metrics, attempt func(ctx context.Context) error) error {
+	for retry := 0; ; retry++ {
+		err := attempt(ctx)
+		if err == nil || retry >= o.Retries || ctx.Err() != nil {
+			return err
+		}
+
+		cause, ok := retryCause(err)
+		if !ok {
+			return err
+		}
+
+		delay := o.delay(retry, err)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+
+		metrics.observeRetry(cause)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	internaltestutil "github.com/xperimental/nextcloud-exporter/internal/testutil"
+)
+
+type testResponse struct {
+	status     int
+	retryAfter string
+}
+
+func TestClientRetry(t *testing.T) {
+	tt := []struct {
+		desc         string
+		retries      int
+		timeout      time.Duration
+		responses    []testResponse
+		wantRequests int32
+		wantRetries  map[string]float64
+		wantErr      error
+	}{
+		{
+			desc:    "no retries",
+			retries: 0,
+			responses: []testResponse{
+				{status: http.StatusBadGateway},
+			},
+			wantRequests: 1,
+			wantErr:      errors.New("gateway error: status code 502"),
+		},
+		{
+			desc:    "success after bad gateway",
+			retries: 2,
+			responses: []testResponse{
+				{status: http.StatusBadGateway},
+				{status: http.StatusOK},
+			},
+			wantRequests: 2,
+			wantRetries: map[string]float64{
+				retryCauseGateway: 1,
+			},
+		},
+		{
+			desc:    "retries exhausted",
+			retries: 2,
+			responses: []testResponse{
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+			},
+			wantRequests: 3,
+			wantRetries: map[string]float64{
+				retryCauseUnavailable: 2,
+			},
+			wantErr: ErrUnavailable,
+		},
+		{
+			desc:    "never retry auth error",
+			retries: 2,
+			responses: []testResponse{
+				{status: http.StatusUnauthorized},
+			},
+			wantRequests: 1,
+			wantErr:      ErrNotAuthorized,
+		},
+		{
+			desc:    "retry-after",
+			retries: 2,
+			responses: []testResponse{
+				{status: http.StatusTooManyRequests, retryAfter: "0"},
+				{status: http.StatusOK},
+			},
+			wantRequests: 2,
+			wantRetries: map[string]float64{
+				retryCauseRatelimit: 1,
+			},
+		},
+		{
+			desc:    "retry-after exceeds timeout",
+			retries: 2,
+			responses: []testResponse{
+				{status: http.StatusTooManyRequests, retryAfter: "120"},
+			},
+			wantRequests: 1,
+			wantErr:      ErrRatelimit,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				i := int(requests.Add(1)) - 1
+				if i >= len(tc.responses) {
+					t.Errorf("unexpected request %d", i+1)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+
+				res := tc.responses[i]
+				if res.retryAfter != "" {
+					w.Header().Set(retryAfterHeader, res.retryAfter)
+				}
+				w.WriteHeader(res.status)
+				fmt.Fprintln(w, "{}")
+			}))
+			defer s.Close()
+
+			metrics := NewMetrics()
+			retry := RetryOptions{
+				Retries: tc.retries,
+				Backoff: time.Millisecond,
+			}
+			client := New(s.URL, "", StaticCredentials("", "token"), time.Second, "test-ua", nil, retry, metrics)
+
+			_, err := client.Info(context.Background())
+			if !internaltestutil.EqualErrorMessage(err, tc.wantErr) {
+				t.Errorf("got error %q, want %q", err, tc.wantErr)
+			}
+
+			if got := requests.Load(); got != tc.wantRequests {
+				t.Errorf("got %d requests, want %d", got, tc.wantRequests)
+			}
+
+			if count := testutil.CollectAndCount(metrics.retries); count != len(tc.wantRetries) {
+				t.Errorf("got %d retry causes, want %d", count, len(tc.wantRetries))
+			}
+
+			for cause, want := range tc.wantRetries {
+				if got := testutil.ToFloat64(metrics.retries.WithLabelValues(cause)); got != want {
+					t.Errorf("got %f retries for %q, want %f", got, cause, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryCause(t *testing.T) {
+	urlError := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://nextcloud.example.com", Err: err}
+	}
+
+	tt := []struct {
+		desc      string
+		err       error
+		wantCause string
+		wantOk    bool
+	}{
+		{
+			desc:      "gateway",
+			err:       fmt.Errorf("%w: status code 502", ErrGateway),
+			wantCause: retryCauseGateway,
+			wantOk:    true,
+		},
+		{
+			desc:   "not authorized",
+			err:    ErrNotAuthorized,
+			wantOk: false,
+		},
+		{
+			desc:      "timeout",
+			err:       urlError(context.DeadlineExceeded),
+			wantCause: retryCauseNetwork,
+			wantOk:    true,
+		},
+		{
+			desc:      "connection refused",
+			err:       urlError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}),
+			wantCause: retryCauseNetwork,
+			wantOk:    true,
+		},
+		{
+			desc:      "connection reset",
+			err:       urlError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}),
+			wantCause: retryCauseNetwork,
+			wantOk:    true,
+		},
+		{
+			desc:   "certificate error",
+			err:    urlError(x509.UnknownAuthorityError{}),
+			wantOk: false,
+		},
+		{
+			desc:   "unknown host",
+			err:    urlError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "nextcloud.example.com", IsNotFound: true}}),
+			wantOk: false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cause, ok := retryCause(tc.err)
+			if ok != tc.wantOk {
+				t.Errorf("got ok %v, want %v", ok, tc.wantOk)
+			}
+
+			if cause != tc.wantCause {
+				t.Errorf("got cause %q, want %q", cause, tc.wantCause)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	retry := RetryOptions{
+		Backoff: time.Second,
+	}
+
+	tt := []struct {
+		desc      string
+		err       error
+		wantDelay time.Duration
+	}{
+		{
+			desc:      "retry-after",
+			err:       &retryAfterError{err: ErrRatelimit, delay: 5 * time.Second},
+			wantDelay: 5 * time.Second,
+		},
+		{
+			desc: "large retry-after",
+			err: withRetryAfter(ErrRatelimit, &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{retryAfterHeader: []string{"4294967295"}},
+			}),
+			wantDelay: maxRetryBackoff,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			if delay := retry.delay(0, tc.err); delay != tc.wantDelay {
+				t.Errorf("got delay %s, want %s", delay, tc.wantDelay)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		value     string
+		wantDelay time.Duration
+		wantOk    bool
+	}{
+		{
+			value:  "",
+			wantOk: false,
+		},
+		{
+			value:     "30",
+			wantDelay: 30 * time.Second,
+			wantOk:    true,
+		},
+		{
+			value:     "Thu, 01 Jan 2026 12:01:00 GMT",
+			wantDelay: time.Minute,
+			wantOk:    true,
+		},
+		{
+			value:     "Thu, 01 Jan 2026 11:00:00 GMT",
+			wantDelay: 0,
+			wantOk:    true,
+		},
+		{
+			value:  "soon",
+			wantOk: false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			delay, ok := parseRetryAfter(tc.value, now)
+			if ok != tc.wantOk {
+				t.Errorf("got ok %v, want %v", ok, tc.wantOk)
+			}
+
+			if delay != tc.wantDelay {
+				t.Errorf("got delay %s, want %s", delay, tc.wantDelay)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+// StatusClient returns the information provided by the status.php endpoint of the Nextcloud instance.
+type StatusClient interface {
+	Status(ctx context.Context) (*serverinfo.Status, error)
+}
+
+// StatusClientFunc is an adapter to allow the use of ordinary functions as StatusClient.
+type StatusClientFunc func(ctx context.Context) (*serverinfo.Status, error)
+
+// Status calls f(ctx).
+func (f StatusClientFunc) Status(ctx context.Context) (*serverinfo.Status, error) {
+	return f(ctx)
+}
+
+// NewStatus creates a client which reads status.php. The endpoint does not need authentication.
+func NewStatus(serverURL string, timeout time.Duration, userAgent string, transport http.RoundTripper) StatusClient {
+	client := newHTTPClient(timeout, transport)
+
+	return StatusClientFunc(func(ctx context.Context) (*serverinfo.Status, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverinfo.StatusURL(serverURL), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("User-Agent", userAgent)
+
+		res, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+
+		if err := checkStatus(res); err != nil {
+			return nil, err
+		}
+
+		status, err := serverinfo.ParseStatus(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("can not parse status: %w", err)
+		}
+
+		return status, nil
+	})
+}
+
+// CacheStatus returns a StatusClient, which only calls statusClient again once its last result is older than interval.
+func CacheStatus(statusClient StatusClient, interval time.Duration) StatusClient {
+	var (
+		lock       sync.Mutex
+		lastUpdate time.Time
+		lastStatus *serverinfo.Status
+		lastErr    error
+	)
+
+	return StatusClientFunc(func(ctx context.Context) (*serverinfo.Status, error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if !lastUpdate.IsZero() && time.Since(lastUpdate) < interval {
+			return lastStatus, lastErr
+		}
+
+		lastStatus, lastErr = statusClient.Status(ctx)
+		lastUpdate = time.Now()
+		return lastStatus, lastErr
+	})
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/xperimental/nextcloud-exporter/internal/testutil"
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+func TestStatusClient(t *testing.T) {
+	tt := []struct {
+		desc       string
+		handler    http.Handler
+		wantStatus *serverinfo.Status
+		wantErr    error
+	}{
+		{
+			desc: "success",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/status.php" {
+					t.Errorf("got path %q, want %q", req.URL.Path, "/status.php")
+				}
+
+				fmt.Fprintln(w, `{"installed":true,"maintenance":true,"needsDbUpgrade":false,"version":"31.0.5.1","versionstring":"31.0.5","edition":"","productname":"Nextcloud","extendedSupport":false}`)
+			}),
+			wantStatus: &serverinfo.Status{
+				Installed:     true,
+				Maintenance:   true,
+				Version:       "31.0.5.1",
+				VersionString: "31.0.5",
+				ProductName:   "Nextcloud",
+			},
+		},
+		{
+			desc: "maintenance",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set(maintenanceModeHeader, "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}),
+			wantErr: ErrMaintenanceMode,
+		},
+		{
+			desc: "parse error",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				fmt.Fprintln(w, "<html>")
+			}),
+			wantErr: errors.New("can not parse status: invalid character '<' looking for beginning of value"),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			s := httptest.NewServer(tc.handler)
+			defer s.Close()
+
+			client := NewStatus(s.URL, time.Second, "test-ua", nil)
+
+			status, err := client.Status(context.Background())
+			if !testutil.EqualErrorMessage(err, tc.wantErr) {
+				t.Errorf("got error %q, want %q", err, tc.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(status, tc.wantStatus); diff != "" {
+				t.Errorf("status differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCacheStatus(t *testing.T) {
+	calls := 0
+	statusClient := StatusClientFunc(func(_ context.Context) (*serverinfo.Status, error) {
+		calls++
+		return &serverinfo.Status{}, nil
+	})
+
+	cached := CacheStatus(statusClient, time.Hour)
+	for i := 0; i < 3; i++ {
+		if _, err := cached.Status(context.Background()); err != nil {
+			t.Fatalf("got error %q", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("got %d calls, want 1", calls)
+	}
+
+	uncached := CacheStatus(statusClient, 0)
+	for i := 0; i < 2; i++ {
+		if _, err := uncached.Status(context.Background()); err != nil {
+			t.Fatalf("got error %q", err)
+		}
+	}
+
+	if calls != 3 {
+		t.Errorf("got %d calls, want 3", calls)
+	}
+}
@@ -3,26 +3,70 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
 	"go.yaml.in/yaml/v2"
+
+	"github.com/xperimental/nextcloud-exporter/internal/tlsconfig"
+	"github.com/xperimental/nextcloud-exporter/internal/transport"
 )
 
 const (
-	envPrefix        = "NEXTCLOUD_"
-	envListenAddress = envPrefix + "LISTEN_ADDRESS"
-	envTimeout       = envPrefix + "TIMEOUT"
-	envServerURL     = envPrefix + "SERVER"
-	envUsername      = envPrefix + "USERNAME"
-	envPassword      = envPrefix + "PASSWORD"
-	envAuthToken     = envPrefix + "AUTH_TOKEN"
-	envTLSSkipVerify = envPrefix + "TLS_SKIP_VERIFY"
-	envInfoApps      = envPrefix + "INFO_APPS"
-	envInfoUpdate    = envPrefix + "INFO_UPDATE"
+	envPrefix           = "NEXTCLOUD_"
+	envListenAddress    = envPrefix + "LISTEN_ADDRESS"
+	envWebConfigFile    = envPrefix + "WEB_CONFIG_FILE"
+	envWebLifecycle     = envPrefix + "WEB_ENABLE_LIFECYCLE"
+	envTimeout          = envPrefix + "TIMEOUT"
+	envServerURL        = envPrefix + "SERVER"
+	envUsername         = envPrefix + "USERNAME"
+	envPassword         = envPrefix + "PASSWORD"
+	envPasswordFile     = envPrefix + "PASSWORD_FILE"
+	envAuthToken        = envPrefix + "AUTH_TOKEN"
+	envAuthTokenFile    = envPrefix + "AUTH_TOKEN_FILE"
+	envTLSSkipVerify    = envPrefix + "TLS_SKIP_VERIFY"
+	envTLSCAFile        = envPrefix + "TLS_CA_FILE"
+	envTLSCertFile      = envPrefix + "TLS_CERT_FILE"
+	envTLSKeyFile       = envPrefix + "TLS_KEY_FILE"
+	envTLSServerName    = envPrefix + "TLS_SERVER_NAME"
+	envTLSMinVersion    = envPrefix + "TLS_MIN_VERSION"
+	envProxyURL         = envPrefix + "PROXY_URL"
+	envHostOverrides    = envPrefix + "HOST_OVERRIDES"
+	envInfoApps         = envPrefix + "INFO_APPS"
+	envInfoUpdate       = envPrefix + "INFO_UPDATE"
+	envInfoAppVersions  = envPrefix + "INFO_APP_VERSIONS"
+	envPollInterval     = envPrefix + "POLL_INTERVAL"
+	envPollMaxAge       = envPrefix + "POLL_MAX_AGE"
+	envRetries          = envPrefix + "RETRIES"
+	envRetryBackoff     = envPrefix + "RETRY_BACKOFF"
+	envCircuitThreshold = envPrefix + "CIRCUIT_BREAKER_THRESHOLD"
+	envCircuitCooldown  = envPrefix + "CIRCUIT_BREAKER_COOLDOWN"
+	envStatus           = envPrefix + "STATUS"
+	envStatusInterval   = envPrefix + "STATUS_INTERVAL"
+	envUpMaintenance    = envPrefix + "UP_DURING_MAINTENANCE"
+	envReadyMaxAge      = envPrefix + "READY_MAX_AGE"
+
+	// envCredentialsDirectory is set by systemd to the directory containing the credentials passed using LoadCredential.
+	envCredentialsDirectory = "CREDENTIALS_DIRECTORY"
+	credentialPassword      = "password"
+	credentialAuthToken     = "auth-token"
+
+	// redactedSecret replaces secrets when showing the configuration.
+	redactedSecret = "<secret>"
+
+	// defaultPollMaxAgeFactor is used to calculate the maximum age of polled information, if it is not set explicitly.
+	defaultPollMaxAgeFactor = 3
+
+	// defaultReadyMaxAge is the maximum age of the last successful contact for readiness, if polling is enabled
+	// and it is not set explicitly. Without polling the server is only contacted during scrapes, which do not
+	// happen while the exporter is not ready, so the check is disabled by default.
+	defaultReadyMaxAge = 5 * time.Minute
 )
 
 // RunMode signals what the main application should do after parsing the options.
@@ -37,6 +81,8 @@ const (
 	RunModeLogin
 	// RunModeVersion shows version information.
 	RunModeVersion
+	// RunModeCheckConfig checks the configuration and shows the effective configuration.
+	RunModeCheckConfig
 )
 
 func (m RunMode) String() string {
@@ -49,6 +95,8 @@ func (m RunMode) String() string {
 		return "login"
 	case RunModeVersion:
 		return "version"
+	case RunModeCheckConfig:
+		return "check-config"
 	default:
 		return "error"
 	}
@@ -56,50 +104,306 @@ func (m RunMode) String() string {
 
 // Config contains the configuration options for nextcloud-exporter.
 type Config struct {
-	ListenAddr    string        `yaml:"listenAddress"`
-	Timeout       time.Duration `yaml:"timeout"`
-	ServerURL     string        `yaml:"server"`
-	Username      string        `yaml:"username"`
-	Password      string        `yaml:"password"`
-	AuthToken     string        `yaml:"authToken"`
-	TLSSkipVerify bool          `yaml:"tlsSkipVerify"`
-	Info          InfoConfig    `yaml:"info"`
-	RunMode       RunMode
+	ListenAddr          string                  `yaml:"listenAddress"`
+	WebConfigFile       string                  `yaml:"webConfigFile"`
+	WebEnableLifecycle  bool                    `yaml:"webEnableLifecycle"`
+	Timeout             time.Duration           `yaml:"timeout"`
+	Retries             int                     `yaml:"retries"`
+	RetryBackoff        time.Duration           `yaml:"retryBackoff"`
+	CircuitBreaker      CircuitBreakerConfig    `yaml:"circuitBreaker"`
+	ServerURL           string                  `yaml:"server"`
+	Username            string                  `yaml:"username"`
+	Password            string                  `yaml:"password"`
+	AuthToken           string                  `yaml:"authToken"`
+	PasswordFile        string                  `yaml:"-"`
+	AuthTokenFile       string                  `yaml:"-"`
+	TLSSkipVerify       bool                    `yaml:"tlsSkipVerify"`
+	TLS                 TLSConfig               `yaml:"tls"`
+	ProxyURL            string                  `yaml:"proxyURL"`
+	Hosts               map[string]string       `yaml:"hosts"`
+	Info                InfoConfig              `yaml:"info"`
+	Status              StatusConfig            `yaml:"status"`
+	UpDuringMaintenance bool                    `yaml:"upDuringMaintenance"`
+	PollInterval        time.Duration           `yaml:"pollInterval"`
+	PollMaxAge          time.Duration           `yaml:"pollMaxAge"`
+	ReadyMaxAge         time.Duration           `yaml:"readyMaxAge"`
+	Modules             map[string]ModuleConfig `yaml:"modules"`
+	Login               LoginConfig             `yaml:"-"`
+	RunMode             RunMode                 `yaml:"-"`
+}
+
+// LoginConfig contains the options for the login mode, which can only be set using command-line parameters.
+type LoginConfig struct {
+	// PasswordFile is the file the app password is written to.
+	PasswordFile string
+	// ConfigFile is the configuration file, which is updated with the new credentials.
+	ConfigFile string
+	// JSON enables printing the credentials as JSON.
+	JSON bool
+	// QRCode enables showing the login URL as a QR code.
+	QRCode bool
+}
+
+// ModuleConfig contains the configuration for one Nextcloud instance, which can be scraped using the probe endpoint.
+type ModuleConfig struct {
+	ServerURL     string            `yaml:"server"`
+	Username      string            `yaml:"username"`
+	Password      string            `yaml:"password"`
+	AuthToken     string            `yaml:"authToken"`
+	PasswordFile  string            `yaml:"-"`
+	AuthTokenFile string            `yaml:"-"`
+	Timeout       time.Duration     `yaml:"timeout"`
+	TLSSkipVerify bool              `yaml:"tlsSkipVerify"`
+	TLS           TLSConfig         `yaml:"tls"`
+	ProxyURL      string            `yaml:"proxyURL"`
+	Hosts         map[string]string `yaml:"hosts"`
+	Info          InfoConfig        `yaml:"info"`
+}
+
+// TLSConfig contains the TLS settings used for connecting to the Nextcloud server.
+type TLSConfig struct {
+	CAFile     string `yaml:"caFile"`
+	CertFile   string `yaml:"certFile"`
+	KeyFile    string `yaml:"keyFile"`
+	ServerName string `yaml:"serverName"`
+	MinVersion string `yaml:"minVersion"`
 }
 
 // InfoConfig contains configuration related to what information is read from serverinfo.
 type InfoConfig struct {
-	Apps   bool `yaml:"apps"`
-	Update bool `yaml:"update"`
+	Apps        bool `yaml:"apps"`
+	AppVersions bool `yaml:"appVersions"`
+	Update      bool `yaml:"update"`
+}
+
+// StatusConfig contains configuration related to reading status.php.
+type StatusConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// CircuitBreakerConfig contains the configuration of the circuit breaker, which stops requests to a failing server.
+type CircuitBreakerConfig struct {
+	Threshold int           `yaml:"threshold"`
+	Cooldown  time.Duration `yaml:"cooldown"`
 }
 
 var (
-	errValidateNoServerURL = errors.New("need to set a server URL")
-	errValidateNoAuth      = errors.New("need to either set username/password or a token")
-	errValidateNoUsername  = errors.New("need to provide a username")
-	errValidateNoPassword  = errors.New("need to provide a password")
+	errValidateNoServerURL  = errors.New("need to set a server URL")
+	errValidateServerScheme = errors.New("server URL needs to start with http:// or https://")
+	errValidateServerHost   = errors.New("server URL needs to contain a host")
+	errValidateServerPath   = errors.New("server URL needs to be the base URL of Nextcloud without the /ocs path")
+	errValidateNoAuth       = errors.New("need to either set username/password or a token")
+	errValidateNoUsername   = errors.New("need to provide a username")
+	errValidateNoPassword   = errors.New("need to provide a password")
+
+	errValidateRetries        = errors.New("number of retries can not be negative")
+	errValidateRetryBackoff   = errors.New("retry backoff can not be negative")
+	errValidateCircuitBreaker = errors.New("circuit breaker threshold and cool-down can not be negative")
+	errValidateStatusInterval = errors.New("status interval can not be negative")
+	errValidatePollInterval   = errors.New("poll interval can not be negative")
+	errValidatePollMaxAge     = errors.New("poll max age needs to be at least the poll interval")
+	errValidateReadyMaxAge    = errors.New("ready max age can not be negative")
+	errValidateTLSKeyPair     = errors.New("TLS certificate and key file need to be set together")
+
+	errValidateAppVersionsNoApps     = errors.New("app versions can only be enabled together with apps")
+	errValidateAppVersionsNoPassword = errors.New("app versions need username and password of an admin user")
+
+	errLoginNoConfigFile = errors.New("--login-update-config needs --config-file")
 )
 
 // Validate checks if the configuration contains all necessary parameters.
+// The server URL can be omitted if at least one module is configured.
 func (c Config) Validate() error {
-	if len(c.ServerURL) == 0 {
+	if len(c.ServerURL) > 0 || len(c.Modules) == 0 {
+		if err := validateServer(c.ServerURL, c.Username, c.Password, c.AuthToken, c.Info); err != nil {
+			return err
+		}
+	}
+
+	if err := c.TLS.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := transport.ParseProxyURL(c.ProxyURL); err != nil {
+		return err
+	}
+
+	if c.Retries < 0 {
+		return errValidateRetries
+	}
+
+	if c.RetryBackoff < 0 {
+		return errValidateRetryBackoff
+	}
+
+	if c.CircuitBreaker.Threshold < 0 || c.CircuitBreaker.Cooldown < 0 {
+		return errValidateCircuitBreaker
+	}
+
+	if c.Status.Interval < 0 {
+		return errValidateStatusInterval
+	}
+
+	if c.PollInterval < 0 {
+		return errValidatePollInterval
+	}
+
+	if c.PollInterval > 0 && c.PollMaxAge < c.PollInterval {
+		return errValidatePollMaxAge
+	}
+
+	if c.ReadyMaxAge < 0 {
+		return errValidateReadyMaxAge
+	}
+
+	for name, module := range c.Modules {
+		if err := module.Validate(); err != nil {
+			return fmt.Errorf("module %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks if the module configuration contains all necessary parameters.
+func (m ModuleConfig) Validate() error {
+	if err := validateServer(m.ServerURL, m.Username, m.Password, m.AuthToken, m.Info); err != nil {
+		return err
+	}
+
+	if err := m.TLS.Validate(); err != nil {
+		return err
+	}
+
+	_, err := transport.ParseProxyURL(m.ProxyURL)
+	return err
+}
+
+// Validate checks if the TLS configuration is consistent.
+func (t TLSConfig) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errValidateTLSKeyPair
+	}
+
+	if _, err := tlsconfig.ParseVersion(t.MinVersion); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TLSOptions returns the options for the TLS connection to the main server.
+func (c Config) TLSOptions() tlsconfig.Options {
+	return c.TLS.options(c.TLSSkipVerify)
+}
+
+// TLSOptions returns the options for the TLS connection to the server of the module.
+func (m ModuleConfig) TLSOptions() tlsconfig.Options {
+	return m.TLS.options(m.TLSSkipVerify)
+}
+
+// TransportOptions returns the options for connecting to the main server. The TLS configuration is not set.
+func (c Config) TransportOptions() transport.Options {
+	return transport.Options{
+		ProxyURL: c.ProxyURL,
+		Hosts:    c.Hosts,
+	}
+}
+
+// TransportOptions returns the options for connecting to the server of the module. The TLS configuration is not set.
+func (m ModuleConfig) TransportOptions() transport.Options {
+	return transport.Options{
+		ProxyURL: m.ProxyURL,
+		Hosts:    m.Hosts,
+	}
+}
+
+// Redacted returns a copy of the configuration without the passwords, tokens and the password of the proxy URL.
+// Secrets read from a file are replaced by the reference to the file.
+func (c Config) Redacted() Config {
+	result := c
+	result.Password = redactSecret(c.Password, c.PasswordFile)
+	result.AuthToken = redactSecret(c.AuthToken, c.AuthTokenFile)
+	result.ProxyURL = redactURL(c.ProxyURL)
+
+	if c.Modules != nil {
+		result.Modules = make(map[string]ModuleConfig, len(c.Modules))
+		for name, module := range c.Modules {
+			module.Password = redactSecret(module.Password, module.PasswordFile)
+			module.AuthToken = redactSecret(module.AuthToken, module.AuthTokenFile)
+			module.ProxyURL = redactURL(module.ProxyURL)
+			result.Modules[name] = module
+		}
+	}
+
+	return result
+}
+
+func redactSecret(value, fileName string) string {
+	switch {
+	case fileName != "":
+		return "@" + fileName
+	case value != "":
+		return redactedSecret
+	default:
+		return ""
+	}
+}
+
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return redactedSecret
+	}
+
+	return parsed.Redacted()
+}
+
+func (t TLSConfig) options(skipVerify bool) tlsconfig.Options {
+	return tlsconfig.Options{
+		CAFile:             t.CAFile,
+		CertFile:           t.CertFile,
+		KeyFile:            t.KeyFile,
+		ServerName:         t.ServerName,
+		MinVersion:         t.MinVersion,
+		InsecureSkipVerify: skipVerify,
+	}
+}
+
+func validateServer(serverURL, username, password, authToken string, info InfoConfig) error {
+	if len(serverURL) == 0 {
 		return errValidateNoServerURL
 	}
 
-	if len(c.AuthToken) == 0 {
-		if len(c.Username) == 0 && len(c.Password) == 0 {
+	if err := validateServerURL(serverURL); err != nil {
+		return err
+	}
+
+	if len(authToken) == 0 {
+		if len(username) == 0 && len(password) == 0 {
 			return errValidateNoAuth
 		}
 
-		if len(c.Username) == 0 {
+		if len(username) == 0 {
 			return errValidateNoUsername
 		}
 
-		if len(c.Password) == 0 {
+		if len(password) == 0 {
 			return errValidateNoPassword
 		}
 	}
 
+	if info.AppVersions {
+		if !info.Apps {
+			return errValidateAppVersionsNoApps
+		}
+
+		if len(username) == 0 || len(password) == 0 {
+			return errValidateAppVersionsNoPassword
+		}
+	}
+
 	return nil
 }
 
@@ -109,116 +413,342 @@ func Get() (Config, error) {
 }
 
 func parseConfig(args []string, envFunc func(string) string) (Config, error) {
-	result, configFile, err := loadConfigFromFlags(args)
+	flagConfig, flagSet, configFile, err := loadConfigFromFlags(args)
 	if err != nil {
 		return Config{}, fmt.Errorf("error parsing flags: %w", err)
 	}
 
+	if flagConfig.RunMode == RunModeHelp || flagConfig.RunMode == RunModeVersion {
+		return flagConfig, nil
+	}
+
+	// The sources are applied in order of increasing precedence. Every source only overrides the fields it sets explicitly.
+	result := mergeConfig(defaultConfig(), flagConfig, flagSet)
+	result.RunMode = flagConfig.RunMode
+	result.Login = flagConfig.Login
+	readyMaxAgeSet := flagSet[fieldReadyMaxAge]
+
 	if configFile != "" {
-		rawFile, err := loadConfigFromFile(configFile)
+		rawFile, fileSet, err := loadConfigFromFile(configFile)
 		if err != nil {
 			return Config{}, fmt.Errorf("error reading configuration file: %w", err)
 		}
 
-		result = mergeConfig(result, rawFile)
+		result = mergeConfig(result, rawFile, fileSet)
+		readyMaxAgeSet = readyMaxAgeSet || fileSet[fieldReadyMaxAge]
+	}
+
+	credentials, credentialSet, err := loadConfigFromCredentials(envFunc(envCredentialsDirectory))
+	if err != nil {
+		return Config{}, fmt.Errorf("error reading systemd credentials: %w", err)
 	}
+	result = mergeConfig(result, credentials, credentialSet)
 
-	env, err := loadConfigFromEnv(envFunc)
+	env, envSet, err := loadConfigFromEnv(envFunc)
 	if err != nil {
 		return Config{}, fmt.Errorf("error reading environment variables: %w", err)
 	}
-	result = mergeConfig(result, env)
+	result = mergeConfig(result, env, envSet)
+	readyMaxAgeSet = readyMaxAgeSet || envSet[fieldReadyMaxAge]
+
+	result.PasswordFile, result.AuthTokenFile = secretFileName(result.Password), secretFileName(result.AuthToken)
+	result.Password, result.AuthToken, err = resolveSecrets(result.Password, result.AuthToken)
+	if err != nil {
+		return Config{}, err
+	}
 
-	if strings.HasPrefix(result.Password, "@") {
-		fileName := strings.TrimPrefix(result.Password, "@")
-		password, err := readPasswordFile(fileName)
+	// the modules inherit the host overrides without the override for the Unix socket of the main server
+	moduleHosts := result.Hosts
+	result.ServerURL, result.Hosts = resolveUnixURL(result.ServerURL, result.Hosts)
+
+	if result.PollInterval > 0 && result.PollMaxAge == 0 {
+		result.PollMaxAge = defaultPollMaxAgeFactor * result.PollInterval
+	}
+
+	if result.PollInterval > 0 && !readyMaxAgeSet {
+		result.ReadyMaxAge = defaultReadyMaxAge
+	}
+
+	for name, module := range result.Modules {
+		module.PasswordFile, module.AuthTokenFile = secretFileName(module.Password), secretFileName(module.AuthToken)
+		module.Password, module.AuthToken, err = resolveSecrets(module.Password, module.AuthToken)
 		if err != nil {
-			return Config{}, fmt.Errorf("can not read password file: %w", err)
+			return Config{}, fmt.Errorf("module %q: %w", name, err)
+		}
+
+		if module.Timeout == 0 {
+			module.Timeout = result.Timeout
+		}
+
+		if module.TLS == (TLSConfig{}) {
+			module.TLS = result.TLS
+		}
+
+		if module.ProxyURL == "" {
+			module.ProxyURL = result.ProxyURL
+		}
+
+		if module.Hosts == nil {
+			module.Hosts = maps.Clone(moduleHosts)
 		}
 
-		result.Password = password
+		module.ServerURL, module.Hosts = resolveUnixURL(module.ServerURL, module.Hosts)
+
+		result.Modules[name] = module
 	}
 
-	if strings.HasPrefix(result.AuthToken, "@") {
-		fileName := strings.TrimPrefix(result.AuthToken, "@")
-		authToken, err := readPasswordFile(fileName)
+	return result, nil
+}
+
+// resolveUnixURL replaces a "unix://" server URL by an HTTP URL and a host override pointing to the socket.
+func resolveUnixURL(serverURL string, hosts map[string]string) (string, map[string]string) {
+	httpURL, address, ok := transport.UnixServerURL(serverURL)
+	if !ok {
+		return serverURL, hosts
+	}
+
+	result := make(map[string]string, len(hosts)+1)
+	for host, target := range hosts {
+		result[host] = target
+	}
+	result[transport.UnixServerHost] = address
+
+	return httpURL, result
+}
+
+// secretFileName returns the name of the file referenced by a "@file" secret or an empty string, if the secret is not read from a file.
+func secretFileName(rawValue string) string {
+	fileName, ok := strings.CutPrefix(rawValue, "@")
+	if !ok {
+		return ""
+	}
+
+	return fileName
+}
+
+func resolveSecrets(rawPassword, rawAuthToken string) (password, authToken string, err error) {
+	password = rawPassword
+	if fileName := secretFileName(password); fileName != "" {
+		password, err = readPasswordFile(fileName)
 		if err != nil {
-			return Config{}, fmt.Errorf("can not read token file: %w", err)
+			return "", "", fmt.Errorf("can not read password file: %w", err)
 		}
+	}
 
-		result.AuthToken = authToken
+	authToken = rawAuthToken
+	if fileName := secretFileName(authToken); fileName != "" {
+		authToken, err = readPasswordFile(fileName)
+		if err != nil {
+			return "", "", fmt.Errorf("can not read token file: %w", err)
+		}
 	}
 
-	return result, nil
+	return password, authToken, nil
 }
 
 func defaultConfig() Config {
 	return Config{
-		ListenAddr: ":9205",
-		Timeout:    5 * time.Second,
+		ListenAddr:   ":9205",
+		Timeout:      5 * time.Second,
+		RetryBackoff: 500 * time.Millisecond,
+		CircuitBreaker: CircuitBreakerConfig{
+			Cooldown: time.Minute,
+		},
+	}
+}
+
+func validateServerURL(serverURL string) error {
+	parsed, err := url.Parse(serverURL)
+	if err != nil {
+		return fmt.Errorf("can not parse server URL: %w", err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return errValidateServerScheme
+	}
+
+	if parsed.Host == "" {
+		return errValidateServerHost
+	}
+
+	if isOCSPath(parsed.Path) {
+		return errValidateServerPath
+	}
+
+	return nil
+}
+
+// isOCSPath returns true, if the path points to the OCS API instead of the base URL of Nextcloud.
+// Other paths starting with "/ocs/" are allowed, because Nextcloud can be installed in such a directory.
+func isOCSPath(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	if strings.HasSuffix(path, "/ocs") {
+		return true
 	}
+
+	for _, api := range []string{"/ocs/v1.php", "/ocs/v2.php"} {
+		if strings.Contains(path+"/", api+"/") {
+			return true
+		}
+	}
+
+	return false
 }
 
-func loadConfigFromFlags(args []string) (result Config, configFile string, err error) {
+func loadConfigFromFlags(args []string) (result Config, set fieldSet, configFile string, err error) {
 	defaults := defaultConfig()
 
 	flags := pflag.NewFlagSet(args[0], pflag.ContinueOnError)
 	flags.StringVarP(&configFile, "config-file", "c", "", "Path to YAML configuration file.")
 	flags.StringVarP(&result.ListenAddr, "addr", "a", defaults.ListenAddr, "Address to listen on for connections.")
+	flags.StringVar(&result.WebConfigFile, "web-config-file", defaults.WebConfigFile, "Path to a web configuration file enabling TLS or authentication for the exporter.")
+	flags.BoolVar(&result.WebEnableLifecycle, "web-enable-lifecycle", defaults.WebEnableLifecycle, "Enable reloading the configuration using POST requests on /-/reload.")
 	flags.DurationVarP(&result.Timeout, "timeout", "t", defaults.Timeout, "Timeout for getting server info document.")
+	flags.IntVar(&result.Retries, "retries", defaults.Retries, "Number of retries for failed requests. Retries are only done while the timeout is not exceeded.")
+	flags.DurationVar(&result.RetryBackoff, "retry-backoff", defaults.RetryBackoff, "Delay before the first retry. Doubled for every further retry.")
+	flags.IntVar(&result.CircuitBreaker.Threshold, "circuit-breaker-threshold", defaults.CircuitBreaker.Threshold, "Number of consecutive failures caused by credentials, rate-limiting or maintenance mode after which requests are stopped. Disabled when zero.")
+	flags.DurationVar(&result.CircuitBreaker.Cooldown, "circuit-breaker-cooldown", defaults.CircuitBreaker.Cooldown, "Duration for which requests are stopped once the circuit breaker opened.")
 	flags.StringVarP(&result.ServerURL, "server", "s", "", "URL to Nextcloud server.")
 	flags.StringVarP(&result.Username, "username", "u", defaults.Username, "Username for connecting to Nextcloud.")
 	flags.StringVarP(&result.Password, "password", "p", defaults.Password, "Password for connecting to Nextcloud.")
 	flags.StringVar(&result.AuthToken, "auth-token", defaults.AuthToken, "Authentication token. Can replace username and password when using Nextcloud 22 or newer.")
 	flags.BoolVar(&result.TLSSkipVerify, "tls-skip-verify", defaults.TLSSkipVerify, "Skip certificate verification of Nextcloud server.")
+	flags.StringVar(&result.TLS.CAFile, "tls-ca-file", defaults.TLS.CAFile, "File containing the CA certificates used for verifying the Nextcloud server.")
+	flags.StringVar(&result.TLS.CertFile, "tls-cert-file", defaults.TLS.CertFile, "File containing the client certificate presented to the Nextcloud server.")
+	flags.StringVar(&result.TLS.KeyFile, "tls-key-file", defaults.TLS.KeyFile, "File containing the key of the client certificate.")
+	flags.StringVar(&result.TLS.ServerName, "tls-server-name", defaults.TLS.ServerName, "Server name used for verifying the certificate of the Nextcloud server, if it differs from the host name.")
+	flags.StringVar(&result.TLS.MinVersion, "tls-min-version", defaults.TLS.MinVersion, "Minimum TLS version used for connecting to the Nextcloud server (1.0, 1.1, 1.2 or 1.3).")
+	flags.StringVar(&result.ProxyURL, "proxy-url", defaults.ProxyURL, "URL of a HTTP or SOCKS5 proxy used for connecting to Nextcloud. By default the proxy environment variables are used.")
+	flags.StringToStringVar(&result.Hosts, "host-override", defaults.Hosts, "Connect to another address instead of the host, given as `host=address`. The address can be a host with optional port or a unix:// socket. Can be repeated.")
 	flags.BoolVar(&result.Info.Apps, "enable-info-apps", defaults.Info.Apps, "Enable gathering of apps-related metrics.")
+	flags.BoolVar(&result.Info.AppVersions, "enable-info-app-versions", defaults.Info.AppVersions, "Enable reading installed versions of apps with available updates. Needs admin username and password.")
 	flags.BoolVar(&result.Info.Update, "enable-info-update", defaults.Info.Update, "Enable metric showing system update availability.")
+	flags.BoolVar(&result.Status.Enabled, "enable-status", defaults.Status.Enabled, "Enable reading status.php, which provides basic availability and version information without credentials.")
+	flags.DurationVar(&result.Status.Interval, "status-interval", defaults.Status.Interval, "Minimum interval between reads of status.php. By default it is read on every scrape.")
+	flags.BoolVar(&result.UpDuringMaintenance, "up-during-maintenance", defaults.UpDuringMaintenance, "Keep nextcloud_up at 1 while the server is in maintenance mode.")
+	flags.DurationVar(&result.PollInterval, "poll-interval", defaults.PollInterval, "Read information from Nextcloud in the background with this interval instead of during the scrape. Disabled when zero.")
+	flags.DurationVar(&result.PollMaxAge, "poll-max-age", defaults.PollMaxAge, "Maximum age of the information read in the background before the server is considered down. Defaults to three times the poll interval.")
+	flags.DurationVar(&result.ReadyMaxAge, "ready-max-age", defaults.ReadyMaxAge, "Maximum age of the last successful contact with Nextcloud for the exporter to be reported as ready. Defaults to 5m when polling is enabled. Disabled when zero.")
 	modeLogin := flags.Bool("login", false, "Use interactive login to create app password.")
+	flags.StringVar(&result.Login.PasswordFile, "login-password-file", "", "Write the app password created by --login to this `file` instead of showing it.")
+	loginUpdateConfig := flags.Bool("login-update-config", false, "Write the credentials created by --login to the configuration file.")
+	flags.BoolVar(&result.Login.JSON, "login-json", false, "Print the credentials created by --login as JSON.")
+	flags.BoolVar(&result.Login.QRCode, "login-qr-code", false, "Show the login URL as QR code.")
 	modeVersion := flags.BoolP("version", "V", false, "Show version information and exit.")
+	modeCheckConfig := flags.Bool("check-config", false, "Check the configuration, show the effective configuration with secrets redacted and exit.")
 
 	if err := flags.Parse(args[1:]); err != nil {
 		if err == pflag.ErrHelp {
 			return Config{
 				RunMode: RunModeHelp,
-			}, "", nil
+			}, nil, "", nil
 		}
 
-		return Config{}, "", err
+		return Config{}, nil, "", err
 	}
 
 	if *modeVersion {
 		return Config{
 			RunMode: RunModeVersion,
-		}, "", nil
+		}, nil, "", nil
 	}
 
 	if *modeLogin {
 		result.RunMode = RunModeLogin
 	}
 
-	return result, configFile, nil
+	if *loginUpdateConfig {
+		if configFile == "" {
+			return Config{}, nil, "", errLoginNoConfigFile
+		}
+
+		result.Login.ConfigFile = configFile
+	}
+
+	if *modeCheckConfig {
+		result.RunMode = RunModeCheckConfig
+	}
+
+	return result, changedFlags(flags), configFile, nil
 }
 
-func loadConfigFromFile(fileName string) (Config, error) {
-	file, err := os.Open(fileName)
+func loadConfigFromFile(fileName string) (Config, fieldSet, error) {
+	data, err := os.ReadFile(fileName)
 	if err != nil {
-		return Config{}, err
+		return Config{}, nil, err
 	}
 
 	var result Config
-	if err := yaml.NewDecoder(file).Decode(&result); err != nil {
-		return Config{}, err
+	if err := yaml.UnmarshalStrict(data, &result); err != nil {
+		return Config{}, nil, err
 	}
 
-	return result, nil
+	set, err := presentKeys(data)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	return result, set, nil
 }
 
-func loadConfigFromEnv(getEnv func(string) string) (Config, error) {
+// loadConfigFromCredentials reads the password and token from the systemd credentials directory, if they are present.
+// The credentials are referenced as files, so that they are read in the same way as secrets using the "@" prefix.
+func loadConfigFromCredentials(dir string) (Config, fieldSet, error) {
+	if dir == "" {
+		return Config{}, nil, nil
+	}
+
+	password, err := credentialFile(dir, credentialPassword)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	authToken, err := credentialFile(dir, credentialAuthToken)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	result := Config{
+		Password:  password,
+		AuthToken: authToken,
+	}
+	set := fieldSet{
+		fieldPassword:  password != "",
+		fieldAuthToken: authToken != "",
+	}
+
+	return result, set, nil
+}
+
+func credentialFile(dir, name string) (string, error) {
+	fileName := filepath.Join(dir, name)
+	if _, err := os.Stat(fileName); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	return "@" + fileName, nil
+}
+
+func loadConfigFromEnv(getEnv func(string) string) (Config, fieldSet, error) {
+	password, err := envSecret(getEnv, envPassword, envPasswordFile)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	authToken, err := envSecret(getEnv, envAuthToken, envAuthTokenFile)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
 	tlsSkipVerify := false
 	if rawValue := getEnv(envTLSSkipVerify); rawValue != "" {
 		value, err := strconv.ParseBool(rawValue)
 		if err != nil {
-			return Config{}, fmt.Errorf("can not parse value for %q: %s", envTLSSkipVerify, rawValue)
+			return Config{}, nil, fmt.Errorf("can not parse value for %q: %s", envTLSSkipVerify, rawValue)
 		}
 		tlsSkipVerify = value
 	}
@@ -227,7 +757,7 @@ func loadConfigFromEnv(getEnv func(string) string) (Config, error) {
 	if rawValue := getEnv(envInfoApps); rawValue != "" {
 		value, err := strconv.ParseBool(rawValue)
 		if err != nil {
-			return Config{}, fmt.Errorf("can not parse value for %q: %s", envInfoApps, rawValue)
+			return Config{}, nil, fmt.Errorf("can not parse value for %q: %s", envInfoApps, rawValue)
 		}
 		infoApps = value
 	}
@@ -236,75 +766,198 @@ func loadConfigFromEnv(getEnv func(string) string) (Config, error) {
 	if rawValue := getEnv(envInfoUpdate); rawValue != "" {
 		value, err := strconv.ParseBool(rawValue)
 		if err != nil {
-			return Config{}, fmt.Errorf("can not parse value for %q: %s", envInfoUpdate, rawValue)
+			return Config{}, nil, fmt.Errorf("can not parse value for %q: %s", envInfoUpdate, rawValue)
 		}
 		infoUpdate = value
 	}
 
+	infoAppVersions := false
+	if rawValue := getEnv(envInfoAppVersions); rawValue != "" {
+		value, err := strconv.ParseBool(rawValue)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("can not parse value for %q: %s", envInfoAppVersions, rawValue)
+		}
+		infoAppVersions = value
+	}
+
+	status := false
+	if rawValue := getEnv(envStatus); rawValue != "" {
+		value, err := strconv.ParseBool(rawValue)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("can not parse value for %q: %s", envStatus, rawValue)
+		}
+		status = value
+	}
+
+	var statusInterval time.Duration
+	if rawValue := getEnv(envStatusInterval); rawValue != "" {
+		value, err := time.ParseDuration(rawValue)
+		if err != nil {
+			return Config{}, nil, err
+		}
+		statusInterval = value
+	}
+
+	webEnableLifecycle := false
+	if rawValue := getEnv(envWebLifecycle); rawValue != "" {
+		value, err := strconv.ParseBool(rawValue)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("can not parse value for %q: %s", envWebLifecycle, rawValue)
+		}
+		webEnableLifecycle = value
+	}
+
+	upDuringMaintenance := false
+	if rawValue := getEnv(envUpMaintenance); rawValue != "" {
+		value, err := strconv.ParseBool(rawValue)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("can not parse value for %q: %s", envUpMaintenance, rawValue)
+		}
+		upDuringMaintenance = value
+	}
+
+	var hosts map[string]string
+	if rawValue := getEnv(envHostOverrides); rawValue != "" {
+		value, err := parseHosts(rawValue)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("can not parse value for %q: %s", envHostOverrides, rawValue)
+		}
+		hosts = value
+	}
+
 	result := Config{
-		ListenAddr:    getEnv(envListenAddress),
-		ServerURL:     getEnv(envServerURL),
-		Username:      getEnv(envUsername),
-		Password:      getEnv(envPassword),
-		AuthToken:     getEnv(envAuthToken),
-		TLSSkipVerify: tlsSkipVerify,
+		ListenAddr:         getEnv(envListenAddress),
+		WebConfigFile:      getEnv(envWebConfigFile),
+		WebEnableLifecycle: webEnableLifecycle,
+		ServerURL:          getEnv(envServerURL),
+		Username:           getEnv(envUsername),
+		Password:           password,
+		AuthToken:          authToken,
+		TLSSkipVerify:      tlsSkipVerify,
+		TLS: TLSConfig{
+			CAFile:     getEnv(envTLSCAFile),
+			CertFile:   getEnv(envTLSCertFile),
+			KeyFile:    getEnv(envTLSKeyFile),
+			ServerName: getEnv(envTLSServerName),
+			MinVersion: getEnv(envTLSMinVersion),
+		},
+		ProxyURL: getEnv(envProxyURL),
+		Hosts:    hosts,
 		Info: InfoConfig{
-			Apps:   infoApps,
-			Update: infoUpdate,
+			Apps:        infoApps,
+			AppVersions: infoAppVersions,
+			Update:      infoUpdate,
+		},
+		Status: StatusConfig{
+			Enabled:  status,
+			Interval: statusInterval,
 		},
+		UpDuringMaintenance: upDuringMaintenance,
 	}
 
 	if raw := getEnv(envTimeout); raw != "" {
 		value, err := time.ParseDuration(raw)
 		if err != nil {
-			return Config{}, err
+			return Config{}, nil, err
 		}
 
 		result.Timeout = value
 	}
 
-	return result, nil
-}
+	if raw := getEnv(envRetries); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("can not parse value for %q: %s", envRetries, raw)
+		}
 
-func mergeConfig(base, override Config) Config {
-	result := base
-	if override.ListenAddr != "" {
-		result.ListenAddr = override.ListenAddr
+		result.Retries = value
 	}
 
-	if override.ServerURL != "" {
-		result.ServerURL = override.ServerURL
+	if raw := getEnv(envRetryBackoff); raw != "" {
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, nil, err
+		}
+
+		result.RetryBackoff = value
 	}
 
-	if override.Username != "" {
-		result.Username = override.Username
+	if raw := getEnv(envCircuitThreshold); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("can not parse value for %q: %s", envCircuitThreshold, raw)
+		}
+
+		result.CircuitBreaker.Threshold = value
 	}
 
-	if override.Password != "" {
-		result.Password = override.Password
+	if raw := getEnv(envCircuitCooldown); raw != "" {
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, nil, err
+		}
+
+		result.CircuitBreaker.Cooldown = value
 	}
 
-	if override.AuthToken != "" {
-		result.AuthToken = override.AuthToken
+	if raw := getEnv(envPollInterval); raw != "" {
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, nil, err
+		}
+
+		result.PollInterval = value
 	}
 
-	if override.Timeout != 0 {
-		result.Timeout = override.Timeout
+	if raw := getEnv(envPollMaxAge); raw != "" {
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, nil, err
+		}
+
+		result.PollMaxAge = value
 	}
 
-	if override.TLSSkipVerify {
-		result.TLSSkipVerify = override.TLSSkipVerify
+	if raw := getEnv(envReadyMaxAge); raw != "" {
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, nil, err
+		}
+
+		result.ReadyMaxAge = value
 	}
 
-	if override.Info.Apps {
-		result.Info.Apps = override.Info.Apps
+	return result, presentEnv(getEnv), nil
+}
+
+// envSecret reads a secret either directly from the variable name or from the file referenced by the variable fileName.
+func envSecret(getEnv func(string) string, name, fileName string) (string, error) {
+	value := getEnv(name)
+	file := getEnv(fileName)
+	if file == "" {
+		return value, nil
 	}
 
-	if override.Info.Update {
-		result.Info.Update = override.Info.Update
+	if value != "" {
+		return "", fmt.Errorf("can not set both %q and %q", name, fileName)
 	}
 
-	return result
+	return "@" + file, nil
+}
+
+// parseHosts parses host overrides in the form "host=address,host=address".
+func parseHosts(value string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		host, address, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || host == "" || address == "" {
+			return nil, fmt.Errorf("invalid host override: %s", pair)
+		}
+
+		result[host] = address
+	}
+
+	return result, nil
 }
 
 func readPasswordFile(fileName string) (string, error) {
@@ -0,0 +1,288 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"go.yaml.in/yaml/v2"
+)
+
+// Names of the configuration fields used for tracking which fields are set explicitly in a configuration source.
+// They are the same as the keys in the configuration file.
+const (
+	fieldListenAddr          = "listenAddress"
+	fieldWebConfigFile       = "webConfigFile"
+	fieldWebLifecycle        = "webEnableLifecycle"
+	fieldTimeout             = "timeout"
+	fieldRetries             = "retries"
+	fieldRetryBackoff        = "retryBackoff"
+	fieldCircuitThreshold    = "circuitBreaker.threshold"
+	fieldCircuitCooldown     = "circuitBreaker.cooldown"
+	fieldServerURL           = "server"
+	fieldUsername            = "username"
+	fieldPassword            = "password"
+	fieldAuthToken           = "authToken"
+	fieldTLSSkipVerify       = "tlsSkipVerify"
+	fieldTLSCAFile           = "tls.caFile"
+	fieldTLSCertFile         = "tls.certFile"
+	fieldTLSKeyFile          = "tls.keyFile"
+	fieldTLSServerName       = "tls.serverName"
+	fieldTLSMinVersion       = "tls.minVersion"
+	fieldProxyURL            = "proxyURL"
+	fieldHosts               = "hosts"
+	fieldInfoApps            = "info.apps"
+	fieldInfoAppVersions     = "info.appVersions"
+	fieldInfoUpdate          = "info.update"
+	fieldStatusEnabled       = "status.enabled"
+	fieldStatusInterval      = "status.interval"
+	fieldUpDuringMaintenance = "upDuringMaintenance"
+	fieldPollInterval        = "pollInterval"
+	fieldPollMaxAge          = "pollMaxAge"
+	fieldReadyMaxAge         = "readyMaxAge"
+	fieldModules             = "modules"
+)
+
+// fieldSet contains the names of the fields, which are set explicitly in a configuration source.
+// Only these fields override the values of sources with lower precedence, even if they contain the zero value.
+type fieldSet map[string]bool
+
+// flagFields maps the names of the command-line flags to the fields they set.
+var flagFields = map[string]string{
+	"addr":                      fieldListenAddr,
+	"web-config-file":           fieldWebConfigFile,
+	"web-enable-lifecycle":      fieldWebLifecycle,
+	"timeout":                   fieldTimeout,
+	"retries":                   fieldRetries,
+	"retry-backoff":             fieldRetryBackoff,
+	"circuit-breaker-threshold": fieldCircuitThreshold,
+	"circuit-breaker-cooldown":  fieldCircuitCooldown,
+	"server":                    fieldServerURL,
+	"username":                  fieldUsername,
+	"password":                  fieldPassword,
+	"auth-token":                fieldAuthToken,
+	"tls-skip-verify":           fieldTLSSkipVerify,
+	"tls-ca-file":               fieldTLSCAFile,
+	"tls-cert-file":             fieldTLSCertFile,
+	"tls-key-file":              fieldTLSKeyFile,
+	"tls-server-name":           fieldTLSServerName,
+	"tls-min-version":           fieldTLSMinVersion,
+	"proxy-url":                 fieldProxyURL,
+	"host-override":             fieldHosts,
+	"enable-info-apps":          fieldInfoApps,
+	"enable-info-app-versions":  fieldInfoAppVersions,
+	"enable-info-update":        fieldInfoUpdate,
+	"enable-status":             fieldStatusEnabled,
+	"status-interval":           fieldStatusInterval,
+	"up-during-maintenance":     fieldUpDuringMaintenance,
+	"poll-interval":             fieldPollInterval,
+	"poll-max-age":              fieldPollMaxAge,
+	"ready-max-age":             fieldReadyMaxAge,
+}
+
+// envFields maps the names of the environment variables to the fields they set.
+var envFields = map[string]string{
+	envListenAddress:    fieldListenAddr,
+	envWebConfigFile:    fieldWebConfigFile,
+	envWebLifecycle:     fieldWebLifecycle,
+	envTimeout:          fieldTimeout,
+	envServerURL:        fieldServerURL,
+	envUsername:         fieldUsername,
+	envPassword:         fieldPassword,
+	envPasswordFile:     fieldPassword,
+	envAuthToken:        fieldAuthToken,
+	envAuthTokenFile:    fieldAuthToken,
+	envTLSSkipVerify:    fieldTLSSkipVerify,
+	envTLSCAFile:        fieldTLSCAFile,
+	envTLSCertFile:      fieldTLSCertFile,
+	envTLSKeyFile:       fieldTLSKeyFile,
+	envTLSServerName:    fieldTLSServerName,
+	envTLSMinVersion:    fieldTLSMinVersion,
+	envProxyURL:         fieldProxyURL,
+	envHostOverrides:    fieldHosts,
+	envInfoApps:         fieldInfoApps,
+	envInfoUpdate:       fieldInfoUpdate,
+	envInfoAppVersions:  fieldInfoAppVersions,
+	envPollInterval:     fieldPollInterval,
+	envPollMaxAge:       fieldPollMaxAge,
+	envRetries:          fieldRetries,
+	envRetryBackoff:     fieldRetryBackoff,
+	envCircuitThreshold: fieldCircuitThreshold,
+	envCircuitCooldown:  fieldCircuitCooldown,
+	envStatus:           fieldStatusEnabled,
+	envStatusInterval:   fieldStatusInterval,
+	envUpMaintenance:    fieldUpDuringMaintenance,
+	envReadyMaxAge:      fieldReadyMaxAge,
+}
+
+// changedFlags returns the fields set by flags, which were passed on the command-line.
+func changedFlags(flags *pflag.FlagSet) fieldSet {
+	result := fieldSet{}
+	flags.Visit(func(flag *pflag.Flag) {
+		if field, ok := flagFields[flag.Name]; ok {
+			result[field] = true
+		}
+	})
+
+	return result
+}
+
+// presentEnv returns the fields set by environment variables, which have a value.
+func presentEnv(getEnv func(string) string) fieldSet {
+	result := fieldSet{}
+	for name, field := range envFields {
+		if getEnv(name) != "" {
+			result[field] = true
+		}
+	}
+
+	return result
+}
+
+// presentKeys returns the fields set in a YAML document. Keys of nested mappings are joined using a dot.
+func presentKeys(data []byte) (fieldSet, error) {
+	var raw yaml.MapSlice
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	result := fieldSet{}
+	addKeys(result, "", raw)
+	return result, nil
+}
+
+func addKeys(result fieldSet, prefix string, values yaml.MapSlice) {
+	for _, item := range values {
+		key := prefix + fmt.Sprint(item.Key)
+		result[key] = true
+
+		if nested, ok := item.Value.(yaml.MapSlice); ok {
+			addKeys(result, key+".", nested)
+		}
+	}
+}
+
+// mergeConfig returns base with the fields in set replaced by their values in override.
+func mergeConfig(base, override Config, set fieldSet) Config {
+	result := base
+	if set[fieldListenAddr] {
+		result.ListenAddr = override.ListenAddr
+	}
+
+	if set[fieldWebConfigFile] {
+		result.WebConfigFile = override.WebConfigFile
+	}
+
+	if set[fieldWebLifecycle] {
+		result.WebEnableLifecycle = override.WebEnableLifecycle
+	}
+
+	if set[fieldServerURL] {
+		result.ServerURL = override.ServerURL
+	}
+
+	if set[fieldUsername] {
+		result.Username = override.Username
+	}
+
+	if set[fieldPassword] {
+		result.Password = override.Password
+	}
+
+	if set[fieldAuthToken] {
+		result.AuthToken = override.AuthToken
+	}
+
+	if set[fieldTimeout] {
+		result.Timeout = override.Timeout
+	}
+
+	if set[fieldRetries] {
+		result.Retries = override.Retries
+	}
+
+	if set[fieldRetryBackoff] {
+		result.RetryBackoff = override.RetryBackoff
+	}
+
+	if set[fieldCircuitThreshold] {
+		result.CircuitBreaker.Threshold = override.CircuitBreaker.Threshold
+	}
+
+	if set[fieldCircuitCooldown] {
+		result.CircuitBreaker.Cooldown = override.CircuitBreaker.Cooldown
+	}
+
+	if set[fieldPollInterval] {
+		result.PollInterval = override.PollInterval
+	}
+
+	if set[fieldPollMaxAge] {
+		result.PollMaxAge = override.PollMaxAge
+	}
+
+	if set[fieldReadyMaxAge] {
+		result.ReadyMaxAge = override.ReadyMaxAge
+	}
+
+	if set[fieldTLSSkipVerify] {
+		result.TLSSkipVerify = override.TLSSkipVerify
+	}
+
+	if set[fieldTLSCAFile] {
+		result.TLS.CAFile = override.TLS.CAFile
+	}
+
+	if set[fieldTLSCertFile] {
+		result.TLS.CertFile = override.TLS.CertFile
+	}
+
+	if set[fieldTLSKeyFile] {
+		result.TLS.KeyFile = override.TLS.KeyFile
+	}
+
+	if set[fieldTLSServerName] {
+		result.TLS.ServerName = override.TLS.ServerName
+	}
+
+	if set[fieldTLSMinVersion] {
+		result.TLS.MinVersion = override.TLS.MinVersion
+	}
+
+	if set[fieldProxyURL] {
+		result.ProxyURL = override.ProxyURL
+	}
+
+	if set[fieldHosts] {
+		result.Hosts = override.Hosts
+	}
+
+	if set[fieldInfoApps] {
+		result.Info.Apps = override.Info.Apps
+	}
+
+	if set[fieldInfoAppVersions] {
+		result.Info.AppVersions = override.Info.AppVersions
+	}
+
+	if set[fieldInfoUpdate] {
+		result.Info.Update = override.Info.Update
+	}
+
+	if set[fieldStatusEnabled] {
+		result.Status.Enabled = override.Status.Enabled
+	}
+
+	if set[fieldStatusInterval] {
+		result.Status.Interval = override.Status.Interval
+	}
+
+	if set[fieldUpDuringMaintenance] {
+		result.UpDuringMaintenance = override.UpDuringMaintenance
+	}
+
+	if set[fieldModules] {
+		result.Modules = override.Modules
+	}
+
+	return result
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPresentKeys(t *testing.T) {
+	tt := []struct {
+		desc    string
+		yaml    string
+		wantSet fieldSet
+	}{
+		{
+			desc:    "empty",
+			yaml:    "",
+			wantSet: fieldSet{},
+		},
+		{
+			desc: "false and zero values",
+			yaml: "tlsSkipVerify: false\nretries: 0\n",
+			wantSet: fieldSet{
+				fieldTLSSkipVerify: true,
+				fieldRetries:       true,
+			},
+		},
+		{
+			desc: "nested",
+			yaml: "info:\n  apps: false\ntls:\n  caFile: ca.pem\n",
+			wantSet: fieldSet{
+				"info":         true,
+				fieldInfoApps:  true,
+				"tls":          true,
+				fieldTLSCAFile: true,
+			},
+		},
+		{
+			desc: "maps",
+			yaml: "hosts:\n  cloud.example.com: 10.0.0.5\nmodules:\n  tenant:\n    server: https://tenant.example.com\n",
+			wantSet: fieldSet{
+				fieldHosts:                true,
+				"hosts.cloud.example.com": true,
+				fieldModules:              true,
+				"modules.tenant":          true,
+				"modules.tenant.server":   true,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			set, err := presentKeys([]byte(tc.yaml))
+			if err != nil {
+				t.Fatalf("got error %q", err)
+			}
+
+			if diff := cmp.Diff(set, tc.wantSet); diff != "" {
+				t.Errorf("fields differ: -got +want\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package login
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mdp/qrterminal/v3"
 	"github.com/sirupsen/logrus"
 )
 
@@ -41,8 +41,9 @@ type passwordInfo struct {
 
 // Login contains the login information gathered during the login session.
 type Login struct {
-	Username string
-	Password string
+	Server   string `json:"server"`
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
 // Client can be used to start an interactive login session with a Nextcloud server.
@@ -56,19 +57,15 @@ type Client struct {
 }
 
 // Init creates a new LoginClient. The session can then be started using StartInteractive.
-func Init(log logrus.FieldLogger, userAgent, serverURL string, tlsSkipVerify bool) *Client {
+func Init(log logrus.FieldLogger, userAgent, serverURL string, transport http.RoundTripper) *Client {
 	return &Client{
 		log:       log,
 		userAgent: userAgent,
 		serverURL: serverURL,
 
 		client: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: tlsSkipVerify,
-				},
-			},
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
 		sleepFunc: func() { time.Sleep(pollInterval) },
 	}
@@ -76,31 +73,37 @@ func Init(log logrus.FieldLogger, userAgent, serverURL string, tlsSkipVerify boo
 
 // StartInteractive starts an interactive login session for the Nextcloud server and user.
 // The end-result of this is an app-password for the exporter which should be used instead of a user password.
-func (c *Client) StartInteractive() error {
+// If qrCode is not nil, the login URL is additionally written to it as a QR code. The credentials are not logged.
+func (c *Client) StartInteractive(qrCode io.Writer) (Login, error) {
 	version, err := c.getMajorVersion()
 	if err != nil {
-		return fmt.Errorf("error getting version: %w", err)
+		return Login{}, fmt.Errorf("error getting version: %w", err)
 	}
 
 	if version < minimumMajorVersion {
-		return fmt.Errorf("Nextcloud version too old for login: %d Minimum: %d", version, minimumMajorVersion) //nolint:staticcheck
+		return Login{}, fmt.Errorf("Nextcloud version too old for login: %d Minimum: %d", version, minimumMajorVersion) //nolint:staticcheck
 	}
 
 	info, err := c.getLoginInfo()
 	if err != nil {
-		return fmt.Errorf("error getting login info: %w", err)
+		return Login{}, fmt.Errorf("error getting login info: %w", err)
 	}
 	c.log.Infof("Please open this URL in a browser: %s", info.LoginURL)
+	if qrCode != nil {
+		qrterminal.GenerateHalfBlock(info.LoginURL, qrterminal.L, qrCode)
+	}
 	c.log.Infoln("Waiting for login ... (Ctrl-C to abort)")
 
 	login, err := c.pollLogin(info.PollInfo)
 	if err != nil {
-		return fmt.Errorf("error during poll: %w", err)
+		return Login{}, fmt.Errorf("error during poll: %w", err)
+	}
+
+	if login.Server == "" {
+		login.Server = c.serverURL
 	}
 
-	c.log.Infof("Username: %s", login.Username)
-	c.log.Infof("Password: %s", login.Password)
-	return nil
+	return login, nil
 }
 
 func (c *Client) doRequest(method, url string, body io.Reader) (*http.Response, error) {
@@ -194,6 +197,7 @@ func (c *Client) pollLogin(info pollInfo) (Login, error) {
 		}
 
 		return Login{
+			Server:   password.Server,
 			Username: password.LoginName,
 			Password: password.AppPassword,
 		}, nil
@@ -149,8 +149,9 @@ func TestPollPassword(t *testing.T) {
 	}{
 		{
 			desc:        "success",
-			testHandler: testHandler(http.StatusOK, `{"loginName": "username", "appPassword": "password"}`),
+			testHandler: testHandler(http.StatusOK, `{"server": "https://cloud.example.com", "loginName": "username", "appPassword": "password"}`),
 			wantLogin: Login{
+				Server:   "https://cloud.example.com",
 				Username: "username",
 				Password: "password",
 			},
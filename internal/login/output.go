@@ -0,0 +1,166 @@
+package login
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	configKeyUsername  = "username"
+	configKeyPassword  = "password"
+	configKeyAuthToken = "authToken"
+)
+
+var errConfigNoMapping = errors.New("configuration file does not contain a mapping")
+
+// WritePasswordFile writes the app password to a file, which is only readable by the owner.
+// The file can be used as password using the "@" prefix.
+func WritePasswordFile(fileName, password string) error {
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	// the mode is only used for new files
+	if err := file.Chmod(0o600); err != nil {
+		file.Close()
+		return err
+	}
+
+	if _, err := fmt.Fprintln(file, password); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// UpdateConfigFile sets the username and password in a YAML configuration file. An authentication token is removed,
+// because it would take precedence over the new credentials. Comments and the order of the other keys are kept.
+// If the password is not a reference to a file using the "@" prefix, the file is made readable only by its owner.
+func UpdateConfigFile(fileName, username, password string) error {
+	// the target of a symbolic link is replaced, so that the link is kept
+	fileName, err := filepath.EvalSymlinks(fileName)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	updated, err := updateConfig(data, username, password)
+	if err != nil {
+		return err
+	}
+
+	// the new content is written to a temporary file first, so that the configuration is never incomplete
+	temp, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	mode := info.Mode().Perm()
+	if !strings.HasPrefix(password, "@") {
+		mode &^= 0o077
+	}
+
+	if err := temp.Chmod(mode); err != nil {
+		temp.Close()
+		return err
+	}
+
+	if _, err := temp.Write(updated); err != nil {
+		temp.Close()
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), fileName)
+}
+
+func updateConfig(data []byte, username, password string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("can not parse configuration file: %w", err)
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml.Node{
+			Kind: yaml.DocumentNode,
+			Content: []*yaml.Node{
+				{Kind: yaml.MappingNode},
+			},
+		}
+	}
+
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errConfigNoMapping
+	}
+	root := doc.Content[0]
+
+	setKey(root, configKeyUsername, username)
+	setKey(root, configKeyPassword, password)
+	removeKey(root, configKeyAuthToken)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// setKey sets the value of key in a mapping, adding the key if it does not exist yet.
+func setKey(mapping *yaml.Node, key, value string) {
+	valueNode := &yaml.Node{}
+	valueNode.SetString(value)
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			previous := mapping.Content[i+1]
+			if previous.Kind == yaml.ScalarNode && valueNode.Style == 0 {
+				valueNode.Style = previous.Style
+			}
+			valueNode.HeadComment = previous.HeadComment
+			valueNode.LineComment = previous.LineComment
+			mapping.Content[i+1] = valueNode
+			return
+		}
+	}
+
+	keyNode := &yaml.Node{}
+	keyNode.SetString(key)
+	mapping.Content = append(mapping.Content, keyNode, valueNode)
+}
+
+func removeKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package login
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xperimental/nextcloud-exporter/internal/testutil"
+)
+
+func TestWritePasswordFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(fileName, []byte("old-password-which-is-longer\n"), 0o644); err != nil {
+		t.Fatalf("error creating file: %s", err)
+	}
+
+	if err := WritePasswordFile(fileName, "app-password"); err != nil {
+		t.Fatalf("got error %q", err)
+	}
+
+	info, err := os.Stat(fileName)
+	if err != nil {
+		t.Fatalf("error reading file info: %s", err)
+	}
+
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("got mode %o, want %o", mode, 0o600)
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+
+	if want := "app-password\n"; string(data) != want {
+		t.Errorf("got content %q, want %q", data, want)
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	tt := []struct {
+		desc       string
+		config     string
+		password   string
+		wantErr    error
+		wantConfig string
+	}{
+		{
+			desc:     "empty",
+			config:   "",
+			password: "app-password",
+			wantConfig: `username: exporter
+password: app-password
+`,
+		},
+		{
+			desc: "replace credentials",
+			config: `# Nextcloud server
+server: https://cloud.example.com
+username: admin # old user
+password: "secret"
+info:
+    apps: true
+`,
+			password: "app-password",
+			wantConfig: `# Nextcloud server
+server: https://cloud.example.com
+username: exporter # old user
+password: "app-password"
+info:
+  apps: true
+`,
+		},
+		{
+			desc: "remove token",
+			config: `server: https://cloud.example.com
+authToken: token
+timeout: 10s
+`,
+			password: "@/etc/nextcloud-exporter/password",
+			wantConfig: `server: https://cloud.example.com
+timeout: 10s
+username: exporter
+password: '@/etc/nextcloud-exporter/password'
+`,
+		},
+		{
+			desc:     "no mapping",
+			config:   "- server\n",
+			password: "app-password",
+			wantErr:  errConfigNoMapping,
+		},
+		{
+			desc:     "invalid",
+			config:   "server: [",
+			password: "app-password",
+			wantErr:  errors.New("can not parse configuration file: yaml: line 1: did not find expected node content"),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := updateConfig([]byte(tc.config), "exporter", tc.password)
+			if !testutil.EqualErrorMessage(err, tc.wantErr) {
+				t.Errorf("got error %q, want %q", err, tc.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(string(config), tc.wantConfig); diff != "" {
+				t.Errorf("config differs: -got +want\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdateConfigFile(t *testing.T) {
+	tt := []struct {
+		desc     string
+		password string
+		symlink  bool
+		wantMode os.FileMode
+		wantData string
+	}{
+		{
+			desc:     "plaintext password",
+			password: "app-password",
+			wantMode: 0o600,
+			wantData: "server: https://cloud.example.com\nusername: exporter\npassword: app-password\n",
+		},
+		{
+			desc:     "password file",
+			password: "@/etc/nextcloud-exporter/password",
+			wantMode: 0o640,
+			wantData: "server: https://cloud.example.com\nusername: exporter\npassword: '@/etc/nextcloud-exporter/password'\n",
+		},
+		{
+			desc:     "symbolic link",
+			password: "app-password",
+			symlink:  true,
+			wantMode: 0o600,
+			wantData: "server: https://cloud.example.com\nusername: exporter\npassword: app-password\n",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			fileName := filepath.Join(dir, "config.yml")
+			if err := os.WriteFile(fileName, []byte("server: https://cloud.example.com\n"), 0o640); err != nil {
+				t.Fatalf("error creating file: %s", err)
+			}
+
+			configFile := fileName
+			if tc.symlink {
+				configFile = filepath.Join(dir, "link.yml")
+				if err := os.Symlink(fileName, configFile); err != nil {
+					t.Fatalf("error creating link: %s", err)
+				}
+			}
+
+			if err := UpdateConfigFile(configFile, "exporter", tc.password); err != nil {
+				t.Fatalf("got error %q", err)
+			}
+
+			if tc.symlink {
+				info, err := os.Lstat(configFile)
+				if err != nil {
+					t.Fatalf("error reading link info: %s", err)
+				}
+
+				if info.Mode()&os.ModeSymlink == 0 {
+					t.Errorf("link was replaced by %s", info.Mode())
+				}
+			}
+
+			info, err := os.Stat(fileName)
+			if err != nil {
+				t.Fatalf("error reading file info: %s", err)
+			}
+
+			if mode := info.Mode().Perm(); mode != tc.wantMode {
+				t.Errorf("got mode %o, want %o", mode, tc.wantMode)
+			}
+
+			data, err := os.ReadFile(fileName)
+			if err != nil {
+				t.Fatalf("error reading file: %s", err)
+			}
+
+			if diff := cmp.Diff(string(data), tc.wantData); diff != "" {
+				t.Errorf("config differs: -got +want\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package metrics
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
@@ -19,13 +23,23 @@ const (
 	labelErrorCauseRatelimit   = "ratelimit"
 	labelErrorCauseUnavailable = "unavailable"
 	labelErrorCauseMaintenance = "maintenance"
+
+	// storagesWarnInterval is the minimum time between two warnings about the same mismatch of the storage counts.
+	storagesWarnInterval = time.Hour
 )
 
+// storagesWarnings is shared by all collectors, because the probe handler creates a new collector for every request.
+var storagesWarnings = newLogLimiter(storagesWarnInterval)
+
 var (
 	systemInfoDesc = prometheus.NewDesc(
 		metricPrefix+"system_info",
 		"Contains meta information about Nextcloud as labels. Value is always 1.",
-		[]string{"version"}, nil)
+		[]string{"version", "memcache_local", "memcache_distributed", "memcache_locking", "filelocking_enabled", "avatars_enabled", "previews_enabled", "debug"}, nil)
+	webserverInfoDesc = prometheus.NewDesc(
+		metricPrefix+"webserver_info",
+		"Contains meta information about the webserver as labels. Value is always 1.",
+		[]string{"server", "version"}, nil)
 	systemUpdateAvailableDesc = prometheus.NewDesc(
 		metricPrefix+"system_update_available",
 		"Contains information whether a system update is available (0 = no, 1 = yes). The available_version label contains the latest available nextcloud version, whereas the version label contains the current installed nextcloud version.",
@@ -38,6 +52,10 @@ var (
 		metricPrefix+"apps_updates_available_total",
 		"Number of apps that have available updates",
 		nil, nil)
+	appUpdateAvailableDesc = prometheus.NewDesc(
+		metricPrefix+"app_update_available",
+		"Contains information about apps with available updates. Value is always 1. The available_version label contains the version of the update, the installed_version label contains the currently installed version, if known.",
+		[]string{"app", "available_version", "installed_version"}, nil)
 	usersDesc = prometheus.NewDesc(
 		metricPrefix+"users_total",
 		"Number of users of the instance.",
@@ -50,14 +68,38 @@ var (
 		metricPrefix+"free_space_bytes",
 		"Free disk space in data directory in bytes.",
 		nil, nil)
+	storagesDesc = prometheus.NewDesc(
+		metricPrefix+"storages_total",
+		"Number of storages by type.",
+		[]string{"type"}, nil)
+	storagesAllDesc = prometheus.NewDesc(
+		metricPrefix+"storages_all_total",
+		"Number of all storages as reported by Nextcloud.",
+		nil, nil)
 	sharesDesc = prometheus.NewDesc(
 		metricPrefix+"shares_total",
 		"Number of shares by type.",
 		[]string{"type"}, nil)
+	sharePermissionsDesc = prometheus.NewDesc(
+		metricPrefix+"shares_permissions_total",
+		"Number of shares by share type and permissions.",
+		[]string{"share_type", "permissions"}, nil)
 	federationsDesc = prometheus.NewDesc(
 		metricPrefix+"shares_federated_total",
 		"Number of federated shares by direction.",
 		[]string{"direction"}, nil)
+	systemLoadDesc = prometheus.NewDesc(
+		metricPrefix+"system_load",
+		"Load average of the host running Nextcloud by window.",
+		[]string{"window"}, nil)
+	systemMemoryDesc = prometheus.NewDesc(
+		metricPrefix+"system_memory_bytes",
+		"Memory of the host running Nextcloud in bytes by type.",
+		[]string{"type"}, nil)
+	systemSwapDesc = prometheus.NewDesc(
+		metricPrefix+"system_swap_bytes",
+		"Swap space of the host running Nextcloud in bytes by type.",
+		[]string{"type"}, nil)
 	activeUsersDesc = prometheus.NewDesc(
 		metricPrefix+"active_users_total",
 		"Number of active users for the last five minutes.",
@@ -78,36 +120,207 @@ var (
 		metricPrefix+"php_memory_limit_bytes",
 		"Configured PHP memory limit in bytes.",
 		nil, nil)
+	phpMaxExecutionTimeDesc = prometheus.NewDesc(
+		metricPrefix+"php_max_execution_time_seconds",
+		"Configured PHP maximum execution time in seconds.",
+		nil, nil)
 	phpMaxUploadSizeDesc = prometheus.NewDesc(
 		metricPrefix+"php_upload_max_size_bytes",
 		"Configured maximum upload size in bytes.",
 		nil, nil)
+	phpOPcacheEnabledDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_enabled",
+		"Indicates if the PHP OPcache is enabled (0 = no, 1 = yes).",
+		nil, nil)
+	phpOPcacheFullDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_cache_full",
+		"Indicates if the PHP OPcache is full (0 = no, 1 = yes).",
+		nil, nil)
+	phpOPcacheMemoryDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_memory_bytes",
+		"Shared memory of the PHP OPcache in bytes by state.",
+		[]string{"state"}, nil)
+	phpOPcacheInternedStringsMemoryDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_interned_strings_memory_bytes",
+		"Memory of the PHP OPcache interned strings buffer in bytes by state.",
+		[]string{"state"}, nil)
+	phpOPcacheInternedStringsDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_interned_strings_total",
+		"Number of strings in the PHP OPcache interned strings buffer.",
+		nil, nil)
+	phpOPcacheCachedScriptsDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_cached_scripts_total",
+		"Number of scripts cached in the PHP OPcache.",
+		nil, nil)
+	phpOPcacheCachedKeysDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_cached_keys_total",
+		"Number of keys cached in the PHP OPcache.",
+		nil, nil)
+	phpOPcacheMaxCachedKeysDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_max_cached_keys",
+		"Maximum number of keys which can be cached in the PHP OPcache.",
+		nil, nil)
+	phpOPcacheHitsDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_hits_total",
+		"Number of PHP OPcache hits since start of the cache.",
+		nil, nil)
+	phpOPcacheMissesDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_misses_total",
+		"Number of PHP OPcache misses since start of the cache.",
+		nil, nil)
+	phpOPcacheHitRateDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_hit_rate_percent",
+		"Hit rate of the PHP OPcache in percent as reported by PHP.",
+		nil, nil)
+	phpOPcacheRestartsDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_restarts_total",
+		"Number of PHP OPcache restarts by cause.",
+		[]string{"cause"}, nil)
+	phpOPcacheStartTimeDesc = prometheus.NewDesc(
+		metricPrefix+"php_opcache_start_time_seconds",
+		"Unix timestamp of the start of the PHP OPcache.",
+		nil, nil)
+	phpAPCuInfoDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_info",
+		"Contains meta information about APCu as labels. Value is always 1.",
+		[]string{"memory_type"}, nil)
+	phpAPCuSlotsDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_slots_total",
+		"Number of slots in the APCu cache.",
+		nil, nil)
+	phpAPCuEntriesDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_entries_total",
+		"Number of entries in the APCu cache.",
+		nil, nil)
+	phpAPCuHitsDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_hits_total",
+		"Number of APCu cache hits since start of the cache.",
+		nil, nil)
+	phpAPCuMissesDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_misses_total",
+		"Number of APCu cache misses since start of the cache.",
+		nil, nil)
+	phpAPCuInsertsDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_inserts_total",
+		"Number of APCu cache inserts since start of the cache.",
+		nil, nil)
+	phpAPCuExpungesDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_expunges_total",
+		"Number of APCu cache expunges since start of the cache.",
+		nil, nil)
+	phpAPCuMemoryDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_memory_used_bytes",
+		"Memory used by entries in the APCu cache in bytes.",
+		nil, nil)
+	phpAPCuStartTimeDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_start_time_seconds",
+		"Unix timestamp of the start of the APCu cache.",
+		nil, nil)
+	phpAPCuSMASegmentsDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_sma_segments_total",
+		"Number of segments of the APCu shared memory allocator.",
+		nil, nil)
+	phpAPCuSMASegmentSizeDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_sma_segment_size_bytes",
+		"Size of one segment of the APCu shared memory allocator in bytes.",
+		nil, nil)
+	phpAPCuSMAAvailableDesc = prometheus.NewDesc(
+		metricPrefix+"php_apcu_sma_available_bytes",
+		"Available memory of the APCu shared memory allocator in bytes.",
+		nil, nil)
 	databaseInfoDesc = prometheus.NewDesc(
 		metricPrefix+"database_info",
 		"Contains meta information about the database as labels. Value is always 1.",
 		[]string{"version", "type"}, nil)
+	lastSuccessDesc = prometheus.NewDesc(
+		metricPrefix+"last_successful_scrape_timestamp_seconds",
+		"Unix timestamp of the last successful read of information from Nextcloud.",
+		nil, nil)
+	snapshotAgeDesc = prometheus.NewDesc(
+		metricPrefix+"snapshot_age_seconds",
+		"Age of the information served by the exporter in seconds. Only available when polling in the background.",
+		nil, nil)
+	maintenanceModeDesc = prometheus.NewDesc(
+		metricPrefix+"maintenance_mode",
+		"Indicates if the Nextcloud instance is in maintenance mode or needs a database upgrade.",
+		nil, nil)
 	databaseSizeDesc = prometheus.NewDesc(
 		metricPrefix+"database_size_bytes",
 		"Size of database in bytes as reported from engine.",
 		nil, nil)
 )
 
+// Options contains the clients and settings used by a collector.
+type Options struct {
+	// InfoClient is used to read the serverinfo document.
+	InfoClient client.InfoClient
+	// AppVersionClient is optional and is used to read the installed version of apps with available updates.
+	AppVersionClient client.AppVersionClient
+	// StatusClient is optional and is used to read status.php.
+	StatusClient client.StatusClient
+	// AppsMetrics enables the metrics related to apps.
+	AppsMetrics bool
+	// UpdateMetrics enables the metric showing system update availability.
+	UpdateMetrics bool
+	// UpDuringMaintenance keeps nextcloud_up at 1 while the instance is in maintenance mode.
+	UpDuringMaintenance bool
+}
+
+// ContextCollector is a prometheus.Collector, which can also use a context when collecting metrics.
+// Collect uses a background context.
+type ContextCollector interface {
+	prometheus.Collector
+	CollectContext(ctx context.Context, ch chan<- prometheus.Metric)
+	// LastSuccess returns the time the information was last read successfully. It is zero if it was never read.
+	LastSuccess() time.Time
+}
+
 type nextcloudCollector struct {
-	log           logrus.FieldLogger
-	infoClient    client.InfoClient
-	appsMetrics   bool
-	updateMetrics bool
+	log          logrus.FieldLogger
+	opts         Options
+	pollInterval time.Duration
+	pollMaxAge   time.Duration
+	nowFunc      func() time.Time
+
+	storagesWarnings *logLimiter
 
 	upMetric           prometheus.Gauge
 	scrapeErrorsMetric *prometheus.CounterVec
+
+	lock        sync.RWMutex
+	lastSuccess time.Time
+	snapshot    snapshot
+	lastPollErr error
+	lastHealth  health
+}
+
+// snapshot contains the result of reading information from the Nextcloud instance.
+type snapshot struct {
+	status      *serverinfo.ServerInfo
+	appVersions map[string]string
+	health      health
+	err         error
+}
+
+// health contains information about the availability of the Nextcloud instance,
+// which is also available when the server info can not be read.
+type health struct {
+	maintenance  bool
+	serverStatus *serverinfo.Status
+}
+
+// NewCollector creates a collector for the Nextcloud instance reachable using the provided clients.
+func NewCollector(log logrus.FieldLogger, opts Options) ContextCollector {
+	return newCollector(log, opts)
 }
 
-func RegisterCollector(log logrus.FieldLogger, infoClient client.InfoClient, appsMetrics bool, updateMetrics bool) error {
-	c := &nextcloudCollector{
-		log:           log,
-		infoClient:    infoClient,
-		appsMetrics:   appsMetrics,
-		updateMetrics: updateMetrics,
+func newCollector(log logrus.FieldLogger, opts Options) *nextcloudCollector {
+	return &nextcloudCollector{
+		log:     log,
+		opts:    opts,
+		nowFunc: time.Now,
+
+		storagesWarnings: storagesWarnings,
 
 		upMetric: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: metricPrefix + "up",
@@ -118,8 +331,6 @@ func RegisterCollector(log logrus.FieldLogger, infoClient client.InfoClient, app
 			Help: "Counts the number of scrape errors by this collector.",
 		}, []string{"cause"}),
 	}
-
-	return prometheus.Register(c)
 }
 
 func (c *nextcloudCollector) Describe(ch chan<- *prometheus.Desc) {
@@ -136,37 +347,165 @@ func (c *nextcloudCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *nextcloudCollector) Collect(ch chan<- prometheus.Metric) {
-	if err := c.collectNextcloud(ch); err != nil {
-		c.log.Errorf("Error during scrape: %s", err)
-
-		cause := labelErrorCauseOther
-		switch {
-		case errors.Is(err, client.ErrNotAuthorized):
-			cause = labelErrorCauseAuth
-		case errors.Is(err, client.ErrRatelimit):
-			cause = labelErrorCauseRatelimit
-		case errors.Is(err, client.ErrUnavailable):
-			cause = labelErrorCauseUnavailable
-		case errors.Is(err, client.ErrMaintenanceMode):
-			cause = labelErrorCauseMaintenance
-		}
-		c.scrapeErrorsMetric.WithLabelValues(cause).Inc()
-		c.upMetric.Set(0)
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext collects the metrics of the Nextcloud instance. The context is used for all requests to the instance.
+// It is not used when polling in the background.
+func (c *nextcloudCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	if c.pollInterval > 0 {
+		c.collectSnapshot(ch)
 	} else {
-		c.upMetric.Set(1)
+		result := c.fetch(ctx)
+		c.collectHealth(ch, result.health)
+
+		if result.err != nil {
+			c.recordError(result.err)
+			c.upMetric.Set(c.downValue(result.health))
+		} else if err := c.collectStatus(ch, result); err != nil {
+			c.recordError(err)
+			c.upMetric.Set(0)
+		} else {
+			c.upMetric.Set(1)
+		}
 	}
 
 	c.upMetric.Collect(ch)
 	c.scrapeErrorsMetric.Collect(ch)
+
+	if lastSuccess := c.LastSuccess(); !lastSuccess.IsZero() {
+		ch <- prometheus.MustNewConstMetric(lastSuccessDesc, prometheus.GaugeValue, float64(lastSuccess.UnixNano())/1e9)
+	}
+}
+
+func (c *nextcloudCollector) LastSuccess() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.lastSuccess
+}
+
+func (c *nextcloudCollector) recordError(err error) {
+	c.log.Errorf("Error during scrape: %s", err)
+
+	cause := labelErrorCauseOther
+	switch {
+	case errors.Is(err, client.ErrNotAuthorized):
+		cause = labelErrorCauseAuth
+	case errors.Is(err, client.ErrRatelimit):
+		cause = labelErrorCauseRatelimit
+	case errors.Is(err, client.ErrUnavailable):
+		cause = labelErrorCauseUnavailable
+	case errors.Is(err, client.ErrMaintenanceMode):
+		cause = labelErrorCauseMaintenance
+	}
+	c.scrapeErrorsMetric.WithLabelValues(cause).Inc()
+}
+
+// downValue returns the value of nextcloud_up used when the information could not be read.
+func (c *nextcloudCollector) downValue(h health) float64 {
+	if h.maintenance && c.opts.UpDuringMaintenance {
+		return 1
+	}
+
+	return 0
 }
 
-func (c *nextcloudCollector) collectNextcloud(ch chan<- prometheus.Metric) error {
-	status, err := c.infoClient()
+// fetch reads the information from the Nextcloud instance and remembers the time of the last successful read.
+func (c *nextcloudCollector) fetch(ctx context.Context) snapshot {
+	var serverStatus *serverinfo.Status
+	var statusErr error
+	if c.opts.StatusClient != nil {
+		serverStatus, statusErr = c.opts.StatusClient.Status(ctx)
+		if statusErr != nil {
+			c.log.Warnf("Error reading status: %s", statusErr)
+		}
+	}
+
+	status, err := c.opts.InfoClient.Info(ctx)
+	h := health{
+		maintenance:  isMaintenance(err, serverStatus, statusErr),
+		serverStatus: serverStatus,
+	}
 	if err != nil {
+		return snapshot{
+			health: h,
+			err:    err,
+		}
+	}
+
+	var appVersions map[string]string
+	if c.opts.AppsMetrics && c.opts.AppVersionClient != nil {
+		appVersions = make(map[string]string, len(status.Data.Nextcloud.System.Apps.Updates))
+		for appID := range status.Data.Nextcloud.System.Apps.Updates {
+			version, err := c.opts.AppVersionClient.AppVersion(ctx, appID)
+			if err != nil {
+				c.log.Warnf("Error getting installed version of app %q: %s", appID, err)
+				continue
+			}
+
+			appVersions[appID] = version
+		}
+	}
+
+	c.checkStorages(status.Data.Nextcloud.Storage)
+
+	c.lock.Lock()
+	c.lastSuccess = c.nowFunc()
+	c.lock.Unlock()
+
+	return snapshot{
+		status:      status,
+		appVersions: appVersions,
+		health:      h,
+	}
+}
+
+// isMaintenance checks if the instance is in maintenance mode, either using the errors of the clients
+// or using the information from status.php.
+func isMaintenance(infoErr error, serverStatus *serverinfo.Status, statusErr error) bool {
+	switch {
+	case errors.Is(infoErr, client.ErrMaintenanceMode), errors.Is(statusErr, client.ErrMaintenanceMode):
+		return true
+	case serverStatus != nil:
+		return serverStatus.Maintenance || serverStatus.NeedsDBUpgrade
+	default:
+		return false
+	}
+}
+
+func (c *nextcloudCollector) collectHealth(ch chan<- prometheus.Metric, h health) {
+	ch <- prometheus.MustNewConstMetric(maintenanceModeDesc, prometheus.GaugeValue, boolValue(h.maintenance))
+
+	if c.opts.StatusClient != nil {
+		if err := collectServerStatus(ch, h.serverStatus); err != nil {
+			c.log.Errorf("Error collecting status metrics: %s", err)
+		}
+	}
+}
+
+func (c *nextcloudCollector) collectStatus(ch chan<- prometheus.Metric, result snapshot) error {
+	if err := readMetrics(ch, result.status, c.opts.AppsMetrics, c.opts.UpdateMetrics); err != nil {
 		return err
 	}
 
-	return readMetrics(ch, status, c.appsMetrics, c.updateMetrics)
+	if c.opts.AppsMetrics {
+		return collectAppUpdates(ch, result.status.Data.Nextcloud.System.Apps, result.appVersions)
+	}
+
+	return nil
+}
+
+func collectAppUpdates(ch chan<- prometheus.Metric, apps serverinfo.Apps, appVersions map[string]string) error {
+	for appID, availableVersion := range apps.Updates {
+		metric, err := prometheus.NewConstMetric(appUpdateAvailableDesc, prometheus.GaugeValue, 1, appID, availableVersion, appVersions[appID])
+		if err != nil {
+			return fmt.Errorf("error creating app update metric for %s: %w", appID, err)
+		}
+		ch <- metric
+	}
+
+	return nil
 }
 
 func readMetrics(ch chan<- prometheus.Metric, status *serverinfo.ServerInfo, appsMetrics bool, updateMetrics bool) error {
@@ -174,27 +513,60 @@ func readMetrics(ch chan<- prometheus.Metric, status *serverinfo.ServerInfo, app
 		return err
 	}
 
+	if err := collectStorages(ch, status.Data.Nextcloud.Storage); err != nil {
+		return err
+	}
+
 	if updateMetrics {
 		if err := collectUpdate(ch, status); err != nil {
 			return err
 		}
 	}
 
+	if err := collectHostResources(ch, status.Data.Nextcloud.System); err != nil {
+		return err
+	}
+
+	if err := collectOPcache(ch, status.Data.Server.PHP.OPcache); err != nil {
+		return err
+	}
+
+	if err := collectAPCu(ch, status.Data.Server.PHP.APCu); err != nil {
+		return err
+	}
+
 	if err := collectShares(ch, status.Data.Nextcloud.Shares); err != nil {
 		return err
 	}
 
+	if err := collectSharePermissions(ch, status.Data.Nextcloud.Shares); err != nil {
+		return err
+	}
+
 	if err := collectFederatedShares(ch, status.Data.Nextcloud.Shares); err != nil {
 		return err
 	}
 
+	system := status.Data.Nextcloud.System
 	systemInfo := []string{
-		status.Data.Nextcloud.System.Version,
+		system.Version,
+		memcacheLabel(system.MemcacheLocal),
+		memcacheLabel(system.MemcacheDistributed),
+		memcacheLabel(system.MemcacheLocking),
+		strconv.FormatBool(system.FilelockingEnabled),
+		strconv.FormatBool(system.EnableAvatars),
+		strconv.FormatBool(system.EnablePreviews),
+		strconv.FormatBool(system.Debug),
 	}
 	if err := collectInfoMetric(ch, systemInfoDesc, systemInfo); err != nil {
 		return err
 	}
 
+	webserver, webserverVersion := splitWebserver(status.Data.Server.Webserver)
+	if err := collectInfoMetric(ch, webserverInfoDesc, []string{webserver, webserverVersion}); err != nil {
+		return err
+	}
+
 	phpInfo := []string{
 		status.Data.Server.PHP.Version,
 	}
@@ -214,8 +586,9 @@ func readMetrics(ch chan<- prometheus.Metric, status *serverinfo.ServerInfo, app
 }
 
 type simpleMetric struct {
-	desc  *prometheus.Desc
-	value float64
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	value     float64
 }
 
 func collectSimpleMetrics(ch chan<- prometheus.Metric, status *serverinfo.ServerInfo, appsMetrics bool) error {
@@ -248,6 +621,10 @@ func collectSimpleMetrics(ch chan<- prometheus.Metric, status *serverinfo.Server
 			desc:  phpMemoryLimitDesc,
 			value: float64(status.Data.Server.PHP.MemoryLimit),
 		},
+		{
+			desc:  phpMaxExecutionTimeDesc,
+			value: float64(status.Data.Server.PHP.MaxExecutionTime),
+		},
 		{
 			desc:  phpMaxUploadSizeDesc,
 			value: float64(status.Data.Server.PHP.UploadMaxFilesize),
@@ -271,8 +648,17 @@ func collectSimpleMetrics(ch chan<- prometheus.Metric, status *serverinfo.Server
 		}...)
 	}
 
+	return collectSimpleMetricList(ch, metrics)
+}
+
+func collectSimpleMetricList(ch chan<- prometheus.Metric, metrics []simpleMetric) error {
 	for _, m := range metrics {
-		metric, err := prometheus.NewConstMetric(m.desc, prometheus.GaugeValue, m.value)
+		valueType := m.valueType
+		if valueType == 0 {
+			valueType = prometheus.GaugeValue
+		}
+
+		metric, err := prometheus.NewConstMetric(m.desc, valueType, m.value)
 		if err != nil {
 			return fmt.Errorf("error creating metric for %s: %w", m.desc, err)
 		}
@@ -282,6 +668,206 @@ func collectSimpleMetrics(ch chan<- prometheus.Metric, status *serverinfo.Server
 	return nil
 }
 
+var loadWindows = []string{"1m", "5m", "15m"}
+
+func collectHostResources(ch chan<- prometheus.Metric, system serverinfo.System) error {
+	load := make(map[string]float64, len(loadWindows))
+	for i, value := range system.CPULoad {
+		if i >= len(loadWindows) {
+			break
+		}
+
+		load[loadWindows[i]] = value
+	}
+	if err := collectMap(ch, systemLoadDesc, load); err != nil {
+		return err
+	}
+
+	if system.Memory.Available {
+		memory := map[string]float64{
+			"total": float64(system.Memory.Total),
+			"free":  float64(system.Memory.Free),
+		}
+		if err := collectMap(ch, systemMemoryDesc, memory); err != nil {
+			return err
+		}
+	}
+
+	if system.Swap.Available {
+		swap := map[string]float64{
+			"total": float64(system.Swap.Total),
+			"free":  float64(system.Swap.Free),
+		}
+		if err := collectMap(ch, systemSwapDesc, swap); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func collectOPcache(ch chan<- prometheus.Metric, opcache serverinfo.OPcache) error {
+	if err := collectSimpleMetricList(ch, []simpleMetric{
+		{
+			desc:  phpOPcacheEnabledDesc,
+			value: boolValue(opcache.Enabled),
+		},
+	}); err != nil {
+		return err
+	}
+
+	if !opcache.Enabled {
+		return nil
+	}
+
+	metrics := []simpleMetric{
+		{
+			desc:  phpOPcacheFullDesc,
+			value: boolValue(opcache.CacheFull),
+		},
+		{
+			desc:  phpOPcacheInternedStringsDesc,
+			value: float64(opcache.InternedStrings.NumberOfStrings),
+		},
+		{
+			desc:  phpOPcacheCachedScriptsDesc,
+			value: float64(opcache.Statistics.CachedScripts),
+		},
+		{
+			desc:  phpOPcacheCachedKeysDesc,
+			value: float64(opcache.Statistics.CachedKeys),
+		},
+		{
+			desc:  phpOPcacheMaxCachedKeysDesc,
+			value: float64(opcache.Statistics.MaxCachedKeys),
+		},
+		{
+			desc:      phpOPcacheHitsDesc,
+			valueType: prometheus.CounterValue,
+			value:     float64(opcache.Statistics.Hits),
+		},
+		{
+			desc:      phpOPcacheMissesDesc,
+			valueType: prometheus.CounterValue,
+			value:     float64(opcache.Statistics.Misses),
+		},
+		{
+			desc:  phpOPcacheHitRateDesc,
+			value: opcache.Statistics.HitRate,
+		},
+		{
+			desc:  phpOPcacheStartTimeDesc,
+			value: float64(opcache.Statistics.StartTime),
+		},
+	}
+	if err := collectSimpleMetricList(ch, metrics); err != nil {
+		return err
+	}
+
+	memory := map[string]float64{
+		"used":   float64(opcache.MemoryUsage.UsedMemory),
+		"free":   float64(opcache.MemoryUsage.FreeMemory),
+		"wasted": float64(opcache.MemoryUsage.WastedMemory),
+	}
+	if err := collectMap(ch, phpOPcacheMemoryDesc, memory); err != nil {
+		return err
+	}
+
+	internedStringsMemory := map[string]float64{
+		"used": float64(opcache.InternedStrings.UsedMemory),
+		"free": float64(opcache.InternedStrings.FreeMemory),
+	}
+	if err := collectMap(ch, phpOPcacheInternedStringsMemoryDesc, internedStringsMemory); err != nil {
+		return err
+	}
+
+	restarts := map[string]float64{
+		"oom":    float64(opcache.Statistics.OOMRestarts),
+		"hash":   float64(opcache.Statistics.HashRestarts),
+		"manual": float64(opcache.Statistics.ManualRestarts),
+	}
+	for cause, value := range restarts {
+		metric, err := prometheus.NewConstMetric(phpOPcacheRestartsDesc, prometheus.CounterValue, value, cause)
+		if err != nil {
+			return fmt.Errorf("error creating metric for %s: %w", phpOPcacheRestartsDesc, err)
+		}
+		ch <- metric
+	}
+
+	return nil
+}
+
+func collectAPCu(ch chan<- prometheus.Metric, apcu serverinfo.APCu) error {
+	if !apcu.Available {
+		return nil
+	}
+
+	if err := collectInfoMetric(ch, phpAPCuInfoDesc, []string{apcu.Cache.MemoryType}); err != nil {
+		return err
+	}
+
+	metrics := []simpleMetric{
+		{
+			desc:  phpAPCuSlotsDesc,
+			value: float64(apcu.Cache.Slots),
+		},
+		{
+			desc:  phpAPCuEntriesDesc,
+			value: float64(apcu.Cache.Entries),
+		},
+		{
+			desc:      phpAPCuHitsDesc,
+			valueType: prometheus.CounterValue,
+			value:     float64(apcu.Cache.Hits),
+		},
+		{
+			desc:      phpAPCuMissesDesc,
+			valueType: prometheus.CounterValue,
+			value:     float64(apcu.Cache.Misses),
+		},
+		{
+			desc:      phpAPCuInsertsDesc,
+			valueType: prometheus.CounterValue,
+			value:     float64(apcu.Cache.Inserts),
+		},
+		{
+			desc:      phpAPCuExpungesDesc,
+			valueType: prometheus.CounterValue,
+			value:     float64(apcu.Cache.Expunges),
+		},
+		{
+			desc:  phpAPCuMemoryDesc,
+			value: float64(apcu.Cache.MemSize),
+		},
+		{
+			desc:  phpAPCuStartTimeDesc,
+			value: float64(apcu.Cache.StartTime),
+		},
+		{
+			desc:  phpAPCuSMASegmentsDesc,
+			value: float64(apcu.SMA.Segments),
+		},
+		{
+			desc:  phpAPCuSMASegmentSizeDesc,
+			value: float64(apcu.SMA.SegmentSize),
+		},
+		{
+			desc:  phpAPCuSMAAvailableDesc,
+			value: float64(apcu.SMA.AvailableMem),
+		},
+	}
+
+	return collectSimpleMetricList(ch, metrics)
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
 func collectUpdate(ch chan<- prometheus.Metric, status *serverinfo.ServerInfo) error {
 	systemInfo := status.Data.Nextcloud.System
 	updateAvailableValue := 0.0
@@ -300,6 +886,37 @@ func collectUpdate(ch chan<- prometheus.Metric, status *serverinfo.ServerInfo) e
 	return nil
 }
 
+// checkStorages warns if the number of storages by type does not add up to the total.
+// The same mismatch is only logged once per storagesWarnInterval, because it would otherwise be logged on every scrape.
+func (c *nextcloudCollector) checkStorages(storage serverinfo.Storage) {
+	sum := storage.StoragesLocal + storage.StoragesHome + storage.StoragesOther
+	if sum == storage.Storages {
+		return
+	}
+
+	message := fmt.Sprintf("Number of storages does not add up: total %d, local %d + home %d + other %d = %d",
+		storage.Storages, storage.StoragesLocal, storage.StoragesHome, storage.StoragesOther, sum)
+	if c.storagesWarnings.allow(message, c.nowFunc()) {
+		c.log.Warn(message)
+	}
+}
+
+func collectStorages(ch chan<- prometheus.Metric, storage serverinfo.Storage) error {
+	// the total is a separate metric, because summing up the types would otherwise count the storages twice
+	metric, err := prometheus.NewConstMetric(storagesAllDesc, prometheus.GaugeValue, float64(storage.Storages))
+	if err != nil {
+		return fmt.Errorf("error creating metric for %s: %w", storagesAllDesc, err)
+	}
+	ch <- metric
+
+	values := make(map[string]float64)
+	values["local"] = float64(storage.StoragesLocal)
+	values["home"] = float64(storage.StoragesHome)
+	values["other"] = float64(storage.StoragesOther)
+
+	return collectMap(ch, storagesDesc, values)
+}
+
 func collectShares(ch chan<- prometheus.Metric, shares serverinfo.Shares) error {
 	values := make(map[string]float64)
 	values["user"] = float64(shares.SharesUser)
@@ -312,6 +929,21 @@ func collectShares(ch chan<- prometheus.Metric, shares serverinfo.Shares) error
 	return collectMap(ch, sharesDesc, values)
 }
 
+func collectSharePermissions(ch chan<- prometheus.Metric, shares serverinfo.Shares) error {
+	for key, count := range shares.Permissions {
+		shareType := shareTypeLabel(key.ShareType)
+		permissions := permissionsLabel(key.Permissions)
+
+		metric, err := prometheus.NewConstMetric(sharePermissionsDesc, prometheus.GaugeValue, float64(count), shareType, permissions)
+		if err != nil {
+			return fmt.Errorf("error creating share permissions metric for %s/%s: %w", shareType, permissions, err)
+		}
+		ch <- metric
+	}
+
+	return nil
+}
+
 func collectFederatedShares(ch chan<- prometheus.Metric, shares serverinfo.Shares) error {
 	values := make(map[string]float64)
 	values["sent"] = float64(shares.FedSent)
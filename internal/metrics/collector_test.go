@@ -0,0 +1,256 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+
+	"github.com/xperimental/nextcloud-exporter/internal/client"
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+func TestCollectorMaintenance(t *testing.T) {
+	tt := []struct {
+		desc                string
+		infoErr             error
+		status              *serverinfo.Status
+		upDuringMaintenance bool
+		wantMaintenance     int
+		wantUp              int
+	}{
+		{
+			desc:            "normal operation",
+			wantMaintenance: 0,
+			wantUp:          1,
+		},
+		{
+			desc:            "maintenance header",
+			infoErr:         client.ErrMaintenanceMode,
+			wantMaintenance: 1,
+			wantUp:          0,
+		},
+		{
+			desc:                "maintenance header, keep up",
+			infoErr:             client.ErrMaintenanceMode,
+			upDuringMaintenance: true,
+			wantMaintenance:     1,
+			wantUp:              1,
+		},
+		{
+			desc:    "maintenance in status",
+			infoErr: client.ErrUnavailable,
+			status: &serverinfo.Status{
+				Installed:   true,
+				Maintenance: true,
+			},
+			upDuringMaintenance: true,
+			wantMaintenance:     1,
+			wantUp:              1,
+		},
+		{
+			desc:    "needs database upgrade",
+			infoErr: client.ErrUnavailable,
+			status: &serverinfo.Status{
+				Installed:      true,
+				NeedsDBUpgrade: true,
+			},
+			wantMaintenance: 1,
+			wantUp:          0,
+		},
+		{
+			desc:    "other error, keep up",
+			infoErr: client.ErrUnavailable,
+			status: &serverinfo.Status{
+				Installed: true,
+			},
+			upDuringMaintenance: true,
+			wantMaintenance:     0,
+			wantUp:              0,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			log := logrus.New()
+			log.SetOutput(io.Discard)
+
+			opts := Options{
+				InfoClient: client.InfoClientFunc(func(_ context.Context) (*serverinfo.ServerInfo, error) {
+					if tc.infoErr != nil {
+						return nil, tc.infoErr
+					}
+
+					return &serverinfo.ServerInfo{}, nil
+				}),
+				UpDuringMaintenance: tc.upDuringMaintenance,
+			}
+			if tc.status != nil {
+				opts.StatusClient = client.StatusClientFunc(func(_ context.Context) (*serverinfo.Status, error) {
+					return tc.status, nil
+				})
+			}
+
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewCollector(log, opts))
+
+			want := fmt.Sprintf(`# HELP nextcloud_maintenance_mode Indicates if the Nextcloud instance is in maintenance mode or needs a database upgrade.
+# TYPE nextcloud_maintenance_mode gauge
+nextcloud_maintenance_mode %d
+# HELP nextcloud_up Indicates if the metrics could be scraped by the exporter.
+# TYPE nextcloud_up gauge
+nextcloud_up %d
+`, tc.wantMaintenance, tc.wantUp)
+			if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "nextcloud_maintenance_mode", "nextcloud_up"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCollectorServerStatus(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	opts := Options{
+		InfoClient: client.InfoClientFunc(func(_ context.Context) (*serverinfo.ServerInfo, error) {
+			return nil, client.ErrNotAuthorized
+		}),
+		StatusClient: client.StatusClientFunc(func(_ context.Context) (*serverinfo.Status, error) {
+			return &serverinfo.Status{
+				Installed:       true,
+				Version:         "31.0.5.1",
+				VersionString:   "31.0.5",
+				ProductName:     "Nextcloud",
+				ExtendedSupport: true,
+			}, nil
+		}),
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector(log, opts))
+
+	want := `# HELP nextcloud_status_extended_support Indicates if the instance has extended support according to status.php (0 = no, 1 = yes).
+# TYPE nextcloud_status_extended_support gauge
+nextcloud_status_extended_support 1
+# HELP nextcloud_status_info Contains the version information of status.php as labels. Value is always 1.
+# TYPE nextcloud_status_info gauge
+nextcloud_status_info{edition="",productname="Nextcloud",version="31.0.5.1",versionstring="31.0.5"} 1
+# HELP nextcloud_status_installed Indicates if Nextcloud is installed according to status.php (0 = no, 1 = yes).
+# TYPE nextcloud_status_installed gauge
+nextcloud_status_installed 1
+# HELP nextcloud_status_maintenance Indicates if maintenance mode is enabled according to status.php (0 = no, 1 = yes).
+# TYPE nextcloud_status_maintenance gauge
+nextcloud_status_maintenance 0
+# HELP nextcloud_status_needs_db_upgrade Indicates if the database needs an upgrade according to status.php (0 = no, 1 = yes).
+# TYPE nextcloud_status_needs_db_upgrade gauge
+nextcloud_status_needs_db_upgrade 0
+# HELP nextcloud_status_up Indicates if status.php could be read by the exporter.
+# TYPE nextcloud_status_up gauge
+nextcloud_status_up 1
+# HELP nextcloud_up Indicates if the metrics could be scraped by the exporter.
+# TYPE nextcloud_up gauge
+nextcloud_up 0
+`
+	metricNames := []string{
+		"nextcloud_status_extended_support",
+		"nextcloud_status_info",
+		"nextcloud_status_installed",
+		"nextcloud_status_maintenance",
+		"nextcloud_status_needs_db_upgrade",
+		"nextcloud_status_up",
+		"nextcloud_up",
+	}
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), metricNames...); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorStorages(t *testing.T) {
+	tt := []struct {
+		desc         string
+		storage      serverinfo.Storage
+		wantWarnings int
+	}{
+		{
+			desc: "consistent",
+			storage: serverinfo.Storage{
+				Storages:      9,
+				StoragesLocal: 1,
+				StoragesHome:  6,
+				StoragesOther: 2,
+			},
+			wantWarnings: 0,
+		},
+		{
+			desc: "mismatch",
+			storage: serverinfo.Storage{
+				Storages:      9,
+				StoragesLocal: 1,
+				StoragesHome:  3,
+				StoragesOther: 2,
+			},
+			wantWarnings: 1,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			log, hook := logrustest.NewNullLogger()
+
+			opts := Options{
+				InfoClient: client.InfoClientFunc(func(_ context.Context) (*serverinfo.ServerInfo, error) {
+					info := &serverinfo.ServerInfo{}
+					info.Data.Nextcloud.Storage = tc.storage
+					return info, nil
+				}),
+			}
+			collector := newCollector(log, opts)
+			collector.storagesWarnings = newLogLimiter(time.Hour)
+
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(collector)
+
+			want := fmt.Sprintf(`# HELP nextcloud_storages_all_total Number of all storages as reported by Nextcloud.
+# TYPE nextcloud_storages_all_total gauge
+nextcloud_storages_all_total %d
+# HELP nextcloud_storages_total Number of storages by type.
+# TYPE nextcloud_storages_total gauge
+nextcloud_storages_total{type="home"} %d
+nextcloud_storages_total{type="local"} %d
+nextcloud_storages_total{type="other"} %d
+`, tc.storage.Storages, tc.storage.StoragesHome, tc.storage.StoragesLocal, tc.storage.StoragesOther)
+
+			// the second scrape checks that the same mismatch is not logged again
+			for i := 0; i < 2; i++ {
+				if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "nextcloud_storages_all_total", "nextcloud_storages_total"); err != nil {
+					t.Error(err)
+				}
+			}
+
+			warnings := 0
+			for _, entry := range hook.AllEntries() {
+				if entry.Level == logrus.WarnLevel && strings.HasPrefix(entry.Message, "Number of storages does not add up") {
+					warnings++
+				}
+			}
+
+			if warnings != tc.wantWarnings {
+				t.Errorf("got %d warnings, want %d", warnings, tc.wantWarnings)
+			}
+		})
+	}
+}
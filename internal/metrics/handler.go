@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	scrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+	// scrapeTimeoutOffset is subtracted from the scrape timeout to leave time for sending the response.
+	scrapeTimeoutOffset = 500 * time.Millisecond
+)
+
+// ScrapeContext returns a context for collecting the metrics requested by r.
+// It is canceled when the request is aborted or the timeout sent by Prometheus is reached.
+func ScrapeContext(r *http.Request) (context.Context, context.CancelFunc) {
+	timeout, ok := scrapeTimeout(r.Header.Get(scrapeTimeoutHeader))
+	if !ok {
+		return context.WithCancel(r.Context())
+	}
+
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+func scrapeTimeout(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0, false
+	}
+
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > scrapeTimeoutOffset {
+		timeout -= scrapeTimeoutOffset
+	}
+
+	return timeout, true
+}
+
+// boundCollector collects the metrics of a ContextCollector using a fixed context.
+type boundCollector struct {
+	ContextCollector
+	ctx context.Context
+}
+
+func (c boundCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(c.ctx, ch)
+}
+
+// WithContext returns a collector which always uses ctx for collecting the metrics of collector.
+func WithContext(ctx context.Context, collector ContextCollector) prometheus.Collector {
+	return boundCollector{
+		ContextCollector: collector,
+		ctx:              ctx,
+	}
+}
+
+type handler struct {
+	log       logrus.FieldLogger
+	gatherer  prometheus.Gatherer
+	collector ContextCollector
+}
+
+// NewHandler creates a handler which serves the metrics of gatherer together with the metrics of collector.
+// The collector uses the context of the scrape request, so requests to Nextcloud are aborted together with the scrape.
+func NewHandler(log logrus.FieldLogger, gatherer prometheus.Gatherer, collector ContextCollector) http.Handler {
+	return &handler{
+		log:       log,
+		gatherer:  gatherer,
+		collector: collector,
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := ScrapeContext(r)
+	defer cancel()
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(WithContext(ctx, h.collector)); err != nil {
+		h.log.Errorf("Failed to register collector: %s", err)
+		http.Error(w, "failed to register collector", http.StatusInternalServerError)
+		return
+	}
+
+	promhttp.HandlerFor(prometheus.Gatherers{h.gatherer, registry}, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
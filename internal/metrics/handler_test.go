@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/nextcloud-exporter/internal/client"
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+func TestScrapeTimeout(t *testing.T) {
+	tt := []struct {
+		desc        string
+		value       string
+		wantTimeout time.Duration
+		wantOk      bool
+	}{
+		{
+			desc:   "empty",
+			value:  "",
+			wantOk: false,
+		},
+		{
+			desc:   "invalid",
+			value:  "ten",
+			wantOk: false,
+		},
+		{
+			desc:   "negative",
+			value:  "-1",
+			wantOk: false,
+		},
+		{
+			desc:        "seconds",
+			value:       "10",
+			wantTimeout: 9500 * time.Millisecond,
+			wantOk:      true,
+		},
+		{
+			desc:        "fraction",
+			value:       "2.5",
+			wantTimeout: 2 * time.Second,
+			wantOk:      true,
+		},
+		{
+			desc:        "shorter than offset",
+			value:       "0.2",
+			wantTimeout: 200 * time.Millisecond,
+			wantOk:      true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			timeout, ok := scrapeTimeout(tc.value)
+			if ok != tc.wantOk {
+				t.Errorf("got ok %v, want %v", ok, tc.wantOk)
+			}
+
+			if timeout != tc.wantTimeout {
+				t.Errorf("got timeout %s, want %s", timeout, tc.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestHandlerContext(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	var gotDeadline time.Duration
+	opts := Options{
+		InfoClient: client.InfoClientFunc(func(ctx context.Context) (*serverinfo.ServerInfo, error) {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return nil, errors.New("context has no deadline")
+			}
+
+			gotDeadline = time.Until(deadline)
+			return &serverinfo.ServerInfo{}, nil
+		}),
+	}
+
+	handler := NewHandler(log, prometheus.NewRegistry(), NewCollector(log, opts))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set(scrapeTimeoutHeader, "3")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", res.Code, http.StatusOK)
+	}
+
+	if !strings.Contains(res.Body.String(), "nextcloud_up 1") {
+		t.Errorf("body does not contain nextcloud_up 1:\n%s", res.Body.String())
+	}
+
+	if gotDeadline <= 0 || gotDeadline > 2500*time.Millisecond {
+		t.Errorf("got deadline in %s, want at most %s", gotDeadline, 2500*time.Millisecond)
+	}
+}
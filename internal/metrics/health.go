@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NewHealthyHandler creates a handler which always reports the exporter as healthy, as long as it is able to respond.
+func NewHealthyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "Healthy.")
+	})
+}
+
+type readyHandler struct {
+	collector ContextCollector
+	maxAge    time.Duration
+	nowFunc   func() time.Time
+}
+
+// NewReadyHandler creates a handler which reports the exporter as ready, if the last successful read of the information
+// by collector is not older than maxAge. The handler does not send requests to Nextcloud.
+// If collector is nil or maxAge is zero, the exporter is always reported as ready.
+func NewReadyHandler(collector ContextCollector, maxAge time.Duration) http.Handler {
+	return &readyHandler{
+		collector: collector,
+		maxAge:    maxAge,
+		nowFunc:   time.Now,
+	}
+}
+
+func (h *readyHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if h.collector == nil || h.maxAge == 0 {
+		fmt.Fprintln(w, "Ready.")
+		return
+	}
+
+	lastSuccess := h.collector.LastSuccess()
+	if lastSuccess.IsZero() {
+		http.Error(w, "Not ready: no successful contact with Nextcloud yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	age := h.nowFunc().Sub(lastSuccess)
+	if age > h.maxAge {
+		http.Error(w, fmt.Sprintf("Not ready: last successful contact with Nextcloud %s ago.", age.Round(time.Second)), http.StatusServiceUnavailable)
+		return
+	}
+
+	fmt.Fprintln(w, "Ready.")
+}
+
+type previousSuccessCollector struct {
+	ContextCollector
+	previous time.Time
+}
+
+// WithPreviousSuccess returns a collector, which reports previous as the last success until collector read the
+// information successfully itself. This keeps the exporter ready when the collector is replaced during a reload.
+func WithPreviousSuccess(collector ContextCollector, previous time.Time) ContextCollector {
+	return previousSuccessCollector{
+		ContextCollector: collector,
+		previous:         previous,
+	}
+}
+
+func (c previousSuccessCollector) LastSuccess() time.Time {
+	lastSuccess := c.ContextCollector.LastSuccess()
+	if c.previous.After(lastSuccess) {
+		return c.previous
+	}
+
+	return lastSuccess
+}
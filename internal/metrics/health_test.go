@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestReadyHandler(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		desc        string
+		noCollector bool
+		lastSuccess time.Time
+		previous    time.Time
+		maxAge      time.Duration
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			desc:        "no collector",
+			noCollector: true,
+			maxAge:      time.Minute,
+			wantStatus:  http.StatusOK,
+			wantBody:    "Ready.",
+		},
+		{
+			desc:       "never contacted",
+			maxAge:     time.Minute,
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "Not ready: no successful contact with Nextcloud yet.",
+		},
+		{
+			desc:        "recent contact",
+			lastSuccess: now.Add(-30 * time.Second),
+			maxAge:      time.Minute,
+			wantStatus:  http.StatusOK,
+			wantBody:    "Ready.",
+		},
+		{
+			desc:        "old contact",
+			lastSuccess: now.Add(-2 * time.Minute),
+			maxAge:      time.Minute,
+			wantStatus:  http.StatusServiceUnavailable,
+			wantBody:    "Not ready: last successful contact with Nextcloud 2m0s ago.",
+		},
+		{
+			desc:       "previous contact",
+			previous:   now.Add(-30 * time.Second),
+			maxAge:     time.Minute,
+			wantStatus: http.StatusOK,
+			wantBody:   "Ready.",
+		},
+		{
+			desc:        "newer than previous contact",
+			lastSuccess: now.Add(-30 * time.Second),
+			previous:    now.Add(-2 * time.Minute),
+			maxAge:      time.Minute,
+			wantStatus:  http.StatusOK,
+			wantBody:    "Ready.",
+		},
+		{
+			desc:       "check disabled",
+			maxAge:     0,
+			wantStatus: http.StatusOK,
+			wantBody:   "Ready.",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			log := logrus.New()
+			log.SetOutput(io.Discard)
+
+			var collector ContextCollector
+			if !tc.noCollector {
+				c := newCollector(log, Options{})
+				c.lastSuccess = tc.lastSuccess
+				collector = WithPreviousSuccess(c, tc.previous)
+			}
+
+			handler := NewReadyHandler(collector, tc.maxAge).(*readyHandler)
+			handler.nowFunc = func() time.Time { return now }
+
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/-/ready", nil))
+
+			if res.Code != tc.wantStatus {
+				t.Errorf("got status %d, want %d", res.Code, tc.wantStatus)
+			}
+
+			if body := strings.TrimSpace(res.Body.String()); body != tc.wantBody {
+				t.Errorf("got body %q, want %q", body, tc.wantBody)
+			}
+		})
+	}
+}
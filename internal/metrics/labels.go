@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+)
+
+// shareTypes maps the numeric share types used by Nextcloud to the labels used in the metrics.
+var shareTypes = map[int]string{
+	0:  "user",
+	1:  "group",
+	2:  "usergroup",
+	3:  "link",
+	4:  "mail",
+	5:  "contact",
+	6:  "federated",
+	7:  "circle",
+	8:  "guest",
+	9:  "federated_group",
+	10: "room",
+	12: "deck",
+	13: "deck_user",
+	15: "sciencemesh",
+}
+
+// sharePermissions contains the names of the permission bits in ascending order.
+var sharePermissions = []struct {
+	bit  int
+	name string
+}{
+	{bit: 1, name: "read"},
+	{bit: 2, name: "update"},
+	{bit: 4, name: "create"},
+	{bit: 8, name: "delete"},
+	{bit: 16, name: "share"},
+}
+
+func shareTypeLabel(shareType int) string {
+	if label, ok := shareTypes[shareType]; ok {
+		return label
+	}
+
+	return strconv.Itoa(shareType)
+}
+
+func permissionsLabel(permissions int) string {
+	if permissions == 0 {
+		return "none"
+	}
+
+	names := []string{}
+	for _, p := range sharePermissions {
+		if permissions&p.bit != 0 {
+			names = append(names, p.name)
+			permissions &^= p.bit
+		}
+	}
+
+	if permissions != 0 {
+		names = append(names, strconv.Itoa(permissions))
+	}
+
+	return strings.Join(names, ",")
+}
+
+const memcachePrefix = `\OC\Memcache\`
+
+// memcacheLabel removes the namespace from the class name of a memcache backend, for example "\OC\Memcache\Redis" becomes "Redis".
+func memcacheLabel(memcache string) string {
+	return strings.TrimPrefix(memcache, memcachePrefix)
+}
+
+// splitWebserver splits the webserver information into the server name and version, for example "nginx/1.14.0".
+// Additional information after the version, like the operating system, is dropped.
+func splitWebserver(webserver string) (server, version string) {
+	server, version, _ = strings.Cut(webserver, "/")
+	version, _, _ = strings.Cut(version, " ")
+	return strings.TrimSpace(server), version
+}
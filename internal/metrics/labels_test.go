@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestShareTypeLabel(t *testing.T) {
+	tt := []struct {
+		shareType int
+		wantLabel string
+	}{
+		{shareType: 0, wantLabel: "user"},
+		{shareType: 3, wantLabel: "link"},
+		{shareType: 6, wantLabel: "federated"},
+		{shareType: 13, wantLabel: "deck_user"},
+		{shareType: 15, wantLabel: "sciencemesh"},
+		{shareType: 42, wantLabel: "42"},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.wantLabel, func(t *testing.T) {
+			t.Parallel()
+
+			label := shareTypeLabel(tc.shareType)
+			if label != tc.wantLabel {
+				t.Errorf("got label %q, want %q", label, tc.wantLabel)
+			}
+		})
+	}
+}
+
+func TestPermissionsLabel(t *testing.T) {
+	tt := []struct {
+		permissions int
+		wantLabel   string
+	}{
+		{permissions: 0, wantLabel: "none"},
+		{permissions: 1, wantLabel: "read"},
+		{permissions: 15, wantLabel: "read,update,create,delete"},
+		{permissions: 17, wantLabel: "read,share"},
+		{permissions: 31, wantLabel: "read,update,create,delete,share"},
+		{permissions: 97, wantLabel: "read,96"},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.wantLabel, func(t *testing.T) {
+			t.Parallel()
+
+			label := permissionsLabel(tc.permissions)
+			if label != tc.wantLabel {
+				t.Errorf("got label %q, want %q", label, tc.wantLabel)
+			}
+		})
+	}
+}
+
+func TestMemcacheLabel(t *testing.T) {
+	tt := []struct {
+		memcache  string
+		wantLabel string
+	}{
+		{memcache: `\OC\Memcache\Redis`, wantLabel: "Redis"},
+		{memcache: `\OC\Memcache\APCu`, wantLabel: "APCu"},
+		{memcache: "none", wantLabel: "none"},
+		{memcache: "", wantLabel: ""},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.memcache, func(t *testing.T) {
+			t.Parallel()
+
+			label := memcacheLabel(tc.memcache)
+			if label != tc.wantLabel {
+				t.Errorf("got label %q, want %q", label, tc.wantLabel)
+			}
+		})
+	}
+}
+
+func TestSplitWebserver(t *testing.T) {
+	tt := []struct {
+		webserver   string
+		wantServer  string
+		wantVersion string
+	}{
+		{webserver: "nginx/1.14.0", wantServer: "nginx", wantVersion: "1.14.0"},
+		{webserver: "Apache/2.4.41 (Ubuntu)", wantServer: "Apache", wantVersion: "2.4.41"},
+		{webserver: "Apache", wantServer: "Apache", wantVersion: ""},
+		{webserver: "", wantServer: "", wantVersion: ""},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.webserver, func(t *testing.T) {
+			t.Parallel()
+
+			server, version := splitWebserver(tc.webserver)
+			if server != tc.wantServer {
+				t.Errorf("got server %q, want %q", server, tc.wantServer)
+			}
+
+			if version != tc.wantVersion {
+				t.Errorf("got version %q, want %q", version, tc.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// logLimiter limits how often the same message is logged.
+type logLimiter struct {
+	interval time.Duration
+
+	lock   sync.Mutex
+	logged map[string]time.Time
+}
+
+func newLogLimiter(interval time.Duration) *logLimiter {
+	return &logLimiter{
+		interval: interval,
+		logged:   make(map[string]time.Time),
+	}
+}
+
+// allow returns true, if message has not been logged during the interval before now.
+// Messages which are allowed are remembered until the interval has passed.
+func (l *logLimiter) allow(message string, now time.Time) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for logged, loggedTime := range l.logged {
+		if now.Sub(loggedTime) >= l.interval {
+			delete(l.logged, logged)
+		}
+	}
+
+	if _, ok := l.logged[message]; ok {
+		return false
+	}
+
+	l.logged[message] = now
+	return true
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// NewPollingCollector creates a collector which reads the information from the Nextcloud instance in the background
+// every interval instead of during the scrape. Scrapes are answered using the last successful result.
+// If the last successful result is older than maxAge, no metrics are served and nextcloud_up is 0.
+// The background polling stops once the context is done.
+func NewPollingCollector(ctx context.Context, log logrus.FieldLogger, opts Options, interval, maxAge time.Duration) ContextCollector {
+	c := newCollector(log, opts)
+	c.pollInterval = interval
+	c.pollMaxAge = maxAge
+
+	go c.runPoll(ctx)
+	return c
+}
+
+func (c *nextcloudCollector) runPoll(ctx context.Context) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		c.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *nextcloudCollector) poll(ctx context.Context) {
+	result := c.fetch(ctx)
+	if result.err != nil {
+		c.recordError(result.err)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lastPollErr = result.err
+	c.lastHealth = result.health
+	if result.err == nil {
+		c.snapshot = result
+	}
+}
+
+func (c *nextcloudCollector) collectSnapshot(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	result := c.snapshot
+	lastPollErr := c.lastPollErr
+	lastSuccess := c.lastSuccess
+	lastHealth := c.lastHealth
+	c.lock.RUnlock()
+
+	c.collectHealth(ch, lastHealth)
+
+	if lastSuccess.IsZero() {
+		c.upMetric.Set(c.downValue(lastHealth))
+		return
+	}
+
+	age := c.nowFunc().Sub(lastSuccess)
+	ch <- prometheus.MustNewConstMetric(snapshotAgeDesc, prometheus.GaugeValue, age.Seconds())
+
+	if age > c.pollMaxAge {
+		c.log.Debugf("Last successful poll is too old: %s", age)
+		c.upMetric.Set(c.downValue(lastHealth))
+		return
+	}
+
+	if err := c.collectStatus(ch, result); err != nil {
+		c.recordError(err)
+		c.upMetric.Set(0)
+		return
+	}
+
+	if lastPollErr != nil {
+		c.upMetric.Set(c.downValue(lastHealth))
+		return
+	}
+
+	c.upMetric.Set(1)
+}
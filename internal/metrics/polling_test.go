@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/nextcloud-exporter/internal/client"
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+func collectAll(c prometheus.Collector) []prometheus.Metric {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	var result []prometheus.Metric
+	for m := range ch {
+		result = append(result, m)
+	}
+	return result
+}
+
+func TestPollingCollector(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	maxAge := time.Minute
+
+	tt := []struct {
+		desc            string
+		results         []error
+		age             time.Duration
+		wantUp          float64
+		wantMetrics     bool
+		wantScrapeError float64
+	}{
+		{
+			desc:        "not polled yet",
+			results:     []error{},
+			wantUp:      0,
+			wantMetrics: false,
+		},
+		{
+			desc:        "success",
+			results:     []error{nil},
+			age:         10 * time.Second,
+			wantUp:      1,
+			wantMetrics: true,
+		},
+		{
+			desc:        "stale",
+			results:     []error{nil},
+			age:         2 * time.Minute,
+			wantUp:      0,
+			wantMetrics: false,
+		},
+		{
+			desc:            "last poll failed",
+			results:         []error{nil, client.ErrRatelimit},
+			age:             10 * time.Second,
+			wantUp:          0,
+			wantMetrics:     true,
+			wantScrapeError: 1,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			log := logrus.New()
+			log.SetOutput(io.Discard)
+
+			var pollErr error
+			infoClient := client.InfoClientFunc(func(_ context.Context) (*serverinfo.ServerInfo, error) {
+				if pollErr != nil {
+					return nil, pollErr
+				}
+
+				return &serverinfo.ServerInfo{}, nil
+			})
+
+			now := start
+			c := newCollector(log, Options{InfoClient: infoClient})
+			c.pollInterval = time.Second
+			c.pollMaxAge = maxAge
+			c.nowFunc = func() time.Time { return now }
+
+			for _, err := range tc.results {
+				pollErr = err
+				c.poll(context.Background())
+			}
+			now = now.Add(tc.age)
+
+			metrics := collectAll(c)
+
+			if up := testutil.ToFloat64(c.upMetric); up != tc.wantUp {
+				t.Errorf("got up %f, want %f", up, tc.wantUp)
+			}
+
+			hasUsers := false
+			for _, m := range metrics {
+				if m.Desc() == usersDesc {
+					hasUsers = true
+				}
+			}
+			if hasUsers != tc.wantMetrics {
+				t.Errorf("got metrics %v, want %v", hasUsers, tc.wantMetrics)
+			}
+
+			scrapeErrors := testutil.ToFloat64(c.scrapeErrorsMetric.WithLabelValues(labelErrorCauseRatelimit))
+			if scrapeErrors != tc.wantScrapeError {
+				t.Errorf("got scrape errors %f, want %f", scrapeErrors, tc.wantScrapeError)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ReloadMetrics contains metrics about reloading the configuration.
+type ReloadMetrics struct {
+	successful       prometheus.Gauge
+	successTimestamp prometheus.Gauge
+}
+
+// RegisterReloadMetrics creates the metrics about reloading the configuration and registers them with the default registry.
+// The initial configuration counts as successful reload.
+func RegisterReloadMetrics() (*ReloadMetrics, error) {
+	m := &ReloadMetrics{
+		successful: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: metricPrefix + "exporter_config_last_reload_successful",
+			Help: "Whether the last configuration reload attempt was successful.",
+		}),
+		successTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: metricPrefix + "exporter_config_last_reload_success_timestamp_seconds",
+			Help: "Timestamp of the last successful configuration reload.",
+		}),
+	}
+	m.Observe(true, time.Now())
+
+	if err := prometheus.Register(m.successful); err != nil {
+		return nil, err
+	}
+
+	if err := prometheus.Register(m.successTimestamp); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Observe records the result of a reload attempt.
+func (m *ReloadMetrics) Observe(success bool, now time.Time) {
+	if !success {
+		m.successful.Set(0)
+		return
+	}
+
+	m.successful.Set(1)
+	m.successTimestamp.Set(float64(now.UnixNano()) / 1e9)
+}
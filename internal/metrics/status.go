@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+var (
+	statusUpDesc = prometheus.NewDesc(
+		metricPrefix+"status_up",
+		"Indicates if status.php could be read by the exporter.",
+		nil, nil)
+	statusInstalledDesc = prometheus.NewDesc(
+		metricPrefix+"status_installed",
+		"Indicates if Nextcloud is installed according to status.php (0 = no, 1 = yes).",
+		nil, nil)
+	statusMaintenanceDesc = prometheus.NewDesc(
+		metricPrefix+"status_maintenance",
+		"Indicates if maintenance mode is enabled according to status.php (0 = no, 1 = yes).",
+		nil, nil)
+	statusNeedsDBUpgradeDesc = prometheus.NewDesc(
+		metricPrefix+"status_needs_db_upgrade",
+		"Indicates if the database needs an upgrade according to status.php (0 = no, 1 = yes).",
+		nil, nil)
+	statusExtendedSupportDesc = prometheus.NewDesc(
+		metricPrefix+"status_extended_support",
+		"Indicates if the instance has extended support according to status.php (0 = no, 1 = yes).",
+		nil, nil)
+	statusInfoDesc = prometheus.NewDesc(
+		metricPrefix+"status_info",
+		"Contains the version information of status.php as labels. Value is always 1.",
+		[]string{"version", "versionstring", "edition", "productname"}, nil)
+)
+
+// collectServerStatus exports the information read from status.php. Only nextcloud_status_up is exported,
+// if status.php could not be read.
+func collectServerStatus(ch chan<- prometheus.Metric, status *serverinfo.Status) error {
+	ch <- prometheus.MustNewConstMetric(statusUpDesc, prometheus.GaugeValue, boolValue(status != nil))
+	if status == nil {
+		return nil
+	}
+
+	metrics := []simpleMetric{
+		{
+			desc:  statusInstalledDesc,
+			value: boolValue(status.Installed),
+		},
+		{
+			desc:  statusMaintenanceDesc,
+			value: boolValue(status.Maintenance),
+		},
+		{
+			desc:  statusNeedsDBUpgradeDesc,
+			value: boolValue(status.NeedsDBUpgrade),
+		},
+		{
+			desc:  statusExtendedSupportDesc,
+			value: boolValue(status.ExtendedSupport),
+		},
+	}
+	if err := collectSimpleMetricList(ch, metrics); err != nil {
+		return err
+	}
+
+	return collectInfoMetric(ch, statusInfoDesc, []string{status.Version, status.VersionString, status.Edition, status.ProductName})
+}
@@ -0,0 +1,69 @@
+package probe
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/nextcloud-exporter/internal/client"
+	"github.com/xperimental/nextcloud-exporter/internal/metrics"
+)
+
+const targetParameter = "target"
+
+// Target contains the information needed to scrape one Nextcloud instance.
+type Target struct {
+	metrics.Options
+	ClientMetrics *client.Metrics
+}
+
+type handler struct {
+	log     logrus.FieldLogger
+	targets map[string]Target
+}
+
+// NewHandler creates a handler which scrapes the target selected by the "target" query parameter.
+// Every request uses a new registry, so the response only contains metrics of the selected target.
+func NewHandler(log logrus.FieldLogger, targets map[string]Target) http.Handler {
+	return &handler{
+		log:     log,
+		targets: targets,
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get(targetParameter)
+	if name == "" {
+		http.Error(w, "target parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	target, ok := h.targets[name]
+	if !ok {
+		http.Error(w, "unknown target: "+name, http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := metrics.ScrapeContext(r)
+	defer cancel()
+
+	log := h.log.WithField("target", name)
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(metrics.WithContext(ctx, metrics.NewCollector(log, target.Options))); err != nil {
+		log.Errorf("Failed to register collector: %s", err)
+		http.Error(w, "failed to register collector", http.StatusInternalServerError)
+		return
+	}
+
+	if target.ClientMetrics != nil {
+		if err := registry.Register(target.ClientMetrics); err != nil {
+			log.Errorf("Failed to register client metrics: %s", err)
+			http.Error(w, "failed to register client metrics", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
@@ -0,0 +1,91 @@
+package probe
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/nextcloud-exporter/internal/client"
+	"github.com/xperimental/nextcloud-exporter/internal/metrics"
+	"github.com/xperimental/nextcloud-exporter/serverinfo"
+)
+
+func TestHandler(t *testing.T) {
+	targets := map[string]Target{
+		"working": {
+			Options: metrics.Options{
+				InfoClient: client.InfoClientFunc(func(_ context.Context) (*serverinfo.ServerInfo, error) {
+					return &serverinfo.ServerInfo{}, nil
+				}),
+			},
+		},
+		"failing": {
+			Options: metrics.Options{
+				InfoClient: client.InfoClientFunc(func(_ context.Context) (*serverinfo.ServerInfo, error) {
+					return nil, client.ErrNotAuthorized
+				}),
+			},
+		},
+	}
+
+	tt := []struct {
+		desc       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			desc:       "missing target",
+			query:      "",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "target parameter is missing",
+		},
+		{
+			desc:       "unknown target",
+			query:      "?target=unknown",
+			wantStatus: http.StatusNotFound,
+			wantBody:   "unknown target: unknown",
+		},
+		{
+			desc:       "success",
+			query:      "?target=working",
+			wantStatus: http.StatusOK,
+			wantBody:   "nextcloud_up 1",
+		},
+		{
+			desc:       "scrape error",
+			query:      "?target=failing",
+			wantStatus: http.StatusOK,
+			wantBody:   `nextcloud_scrape_errors_total{cause="auth"} 1`,
+		},
+	}
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	handler := NewHandler(log, targets)
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/probe"+tc.query, nil)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tc.wantStatus)
+			}
+
+			if body := rec.Body.String(); !strings.Contains(body, tc.wantBody) {
+				t.Errorf("body does not contain %q:\n%s", tc.wantBody, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errKeyPair = errors.New("certificate and key file need to be set together")
+	errNoCerts = errors.New("no certificates found in CA file")
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Options contains the TLS settings used for connecting to a Nextcloud server.
+type Options struct {
+	// CAFile contains the certificates of the CAs used for verifying the server. The system CAs are used if it is empty.
+	CAFile string
+	// CertFile and KeyFile contain the client certificate presented to the server.
+	CertFile string
+	KeyFile  string
+	// ServerName is used for verifying the certificate of the server instead of the host name.
+	ServerName string
+	// MinVersion is the minimum TLS version, for example "1.2".
+	MinVersion string
+	// InsecureSkipVerify disables verification of the server certificate.
+	InsecureSkipVerify bool
+}
+
+// ParseVersion returns the TLS version constant for a version string like "1.2" or "TLS1.2".
+// An empty string results in zero, which uses the default of the crypto/tls package.
+func ParseVersion(value string) (uint16, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	version, ok := tlsVersions[strings.TrimPrefix(strings.ToUpper(value), "TLS")]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version: %s", value)
+	}
+
+	return version, nil
+}
+
+// Config provides the TLS configuration used for connecting to a Nextcloud server. The CA and client certificate
+// files are read again when they change, so that renewed certificates are used without a restart.
+type Config struct {
+	base  *tls.Config
+	roots *reloader[*x509.CertPool]
+
+	lock    sync.Mutex
+	pool    *x509.CertPool
+	current *tls.Config
+}
+
+// New creates a TLS configuration from the options.
+func New(opts Options) (*Config, error) {
+	minVersion, err := ParseVersion(opts.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Config{
+		base: &tls.Config{
+			ServerName:         opts.ServerName,
+			MinVersion:         minVersion,
+			InsecureSkipVerify: opts.InsecureSkipVerify,
+		},
+	}
+
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, errKeyPair
+	}
+
+	if opts.CertFile != "" {
+		keyPair := &reloader[*tls.Certificate]{
+			files: []string{opts.CertFile, opts.KeyFile},
+			load: func() (*tls.Certificate, error) {
+				cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+				if err != nil {
+					return nil, fmt.Errorf("can not load client certificate: %w", err)
+				}
+
+				return &cert, nil
+			},
+		}
+		if _, err := keyPair.get(); err != nil {
+			return nil, err
+		}
+
+		result.base.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return keyPair.get()
+		}
+	}
+
+	if opts.CAFile != "" && !opts.InsecureSkipVerify {
+		result.roots = &reloader[*x509.CertPool]{
+			files: []string{opts.CAFile},
+			load: func() (*x509.CertPool, error) {
+				return loadCertPool(opts.CAFile)
+			},
+		}
+		if _, err := result.roots.get(); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// TLSConfig returns the current TLS configuration. The server certificate is verified by crypto/tls, so a new
+// configuration containing the current CAs is returned when the CA file changed. Connections need to be created
+// using the latest configuration returned by this method.
+func (c *Config) TLSConfig() *tls.Config {
+	if c.roots == nil {
+		return c.base
+	}
+
+	// the previous pool is kept if loading fails, so there is always a pool after New succeeded
+	pool, _ := c.roots.get()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if pool != c.pool {
+		c.current = c.base.Clone()
+		c.current.RootCAs = pool
+		c.pool = pool
+	}
+
+	return c.current
+}
+
+func loadCertPool(fileName string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("can not read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errNoCerts
+	}
+
+	return pool, nil
+}
+
+// reloader keeps the value loaded from a set of files and loads it again once one of the files changes.
+// If loading the changed files fails, the previous value is kept.
+type reloader[T any] struct {
+	files []string
+	load  func() (T, error)
+
+	lock    sync.Mutex
+	value   T
+	loaded  bool
+	modTime time.Time
+}
+
+func (r *reloader[T]) get() (T, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	modTime, err := latestModTime(r.files)
+	if err == nil && r.loaded && !modTime.After(r.modTime) {
+		return r.value, nil
+	}
+
+	value, loadErr := r.load()
+	if loadErr != nil {
+		if r.loaded {
+			return r.value, nil
+		}
+
+		return value, loadErr
+	}
+
+	r.value = value
+	r.loaded = true
+	r.modTime = modTime
+	return value, nil
+}
+
+func latestModTime(files []string) (time.Time, error) {
+	var result time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(result) {
+			result = info.ModTime()
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,359 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xperimental/nextcloud-exporter/internal/testutil"
+)
+
+func TestParseVersion(t *testing.T) {
+	tt := []struct {
+		desc        string
+		value       string
+		wantVersion uint16
+		wantErr     error
+	}{
+		{
+			desc:        "empty",
+			value:       "",
+			wantVersion: 0,
+		},
+		{
+			desc:        "number",
+			value:       "1.2",
+			wantVersion: tls.VersionTLS12,
+		},
+		{
+			desc:        "prefix",
+			value:       "TLS1.3",
+			wantVersion: tls.VersionTLS13,
+		},
+		{
+			desc:    "unknown",
+			value:   "2.0",
+			wantErr: errors.New("unknown TLS version: 2.0"),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			version, err := ParseVersion(tc.value)
+			if !testutil.EqualErrorMessage(err, tc.wantErr) {
+				t.Errorf("got error %q, want %q", err, tc.wantErr)
+			}
+
+			if version != tc.wantVersion {
+				t.Errorf("got version %x, want %x", version, tc.wantVersion)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s.Config.ErrorLog = log.New(io.Discard, "", 0)
+	s.StartTLS()
+	t.Cleanup(s.Close)
+
+	dir := t.TempDir()
+	caFile := writeFile(t, dir, "ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}))
+	emptyFile := writeFile(t, dir, "empty.pem", []byte{})
+
+	tt := []struct {
+		desc       string
+		opts       Options
+		wantErr    error
+		wantReqErr bool
+	}{
+		{
+			desc:       "system CAs",
+			opts:       Options{},
+			wantReqErr: true,
+		},
+		{
+			desc: "skip verify",
+			opts: Options{
+				InsecureSkipVerify: true,
+			},
+		},
+		{
+			desc: "CA file",
+			opts: Options{
+				CAFile: caFile,
+			},
+		},
+		{
+			desc: "server name",
+			opts: Options{
+				CAFile:     caFile,
+				ServerName: "example.com",
+			},
+		},
+		{
+			desc: "wrong server name",
+			opts: Options{
+				CAFile:     caFile,
+				ServerName: "nextcloud.example.org",
+			},
+			wantReqErr: true,
+		},
+		{
+			desc: "min version",
+			opts: Options{
+				CAFile:     caFile,
+				MinVersion: "1.3",
+			},
+		},
+		{
+			desc: "empty CA file",
+			opts: Options{
+				CAFile: emptyFile,
+			},
+			wantErr: errNoCerts,
+		},
+		{
+			desc: "missing key",
+			opts: Options{
+				CertFile: caFile,
+			},
+			wantErr: errKeyPair,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := New(tc.opts)
+			if !testutil.EqualErrorMessage(err, tc.wantErr) {
+				t.Errorf("got error %q, want %q", err, tc.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			_, err = get(config.TLSConfig(), s.URL)
+			if (err != nil) != tc.wantReqErr {
+				t.Errorf("got request error %v, want error %v", err, tc.wantReqErr)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	s.TLS = &tls.Config{
+		ClientAuth: tls.RequireAnyClientCert,
+	}
+	s.Config.ErrorLog = log.New(io.Discard, "", 0)
+	s.StartTLS()
+	defer s.Close()
+
+	dir := t.TempDir()
+	caFile := writeFile(t, dir, "ca.pem", newCertificate(t, "other-ca").cert)
+	first := newCertificate(t, "first")
+	certFile := writeFile(t, dir, "cert.pem", first.cert)
+	keyFile := writeFile(t, dir, "key.pem", first.key)
+
+	config, err := New(Options{
+		CAFile:   caFile,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	})
+	if err != nil {
+		t.Fatalf("error creating config: %s", err)
+	}
+
+	if _, err := get(config.TLSConfig(), s.URL); err == nil {
+		t.Error("expected error using wrong CA")
+	}
+
+	update(t, caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}))
+	body, err := get(config.TLSConfig(), s.URL)
+	if err != nil {
+		t.Fatalf("error after updating CA: %s", err)
+	}
+
+	if body != "first" {
+		t.Errorf("got client certificate %q, want %q", body, "first")
+	}
+
+	second := newCertificate(t, "second")
+	update(t, certFile, second.cert)
+	update(t, keyFile, second.key)
+	body, err = get(config.TLSConfig(), s.URL)
+	if err != nil {
+		t.Fatalf("error after updating client certificate: %s", err)
+	}
+
+	if body != "second" {
+		t.Errorf("got client certificate %q, want %q", body, "second")
+	}
+}
+
+func TestVerifyIPHost(t *testing.T) {
+	cert := newCertificate(t, "nextcloud", "other.example.com")
+	keyPair, err := tls.X509KeyPair(cert.cert, cert.key)
+	if err != nil {
+		t.Fatalf("error loading key pair: %s", err)
+	}
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s.TLS = &tls.Config{
+		Certificates: []tls.Certificate{keyPair},
+	}
+	s.Config.ErrorLog = log.New(io.Discard, "", 0)
+	s.StartTLS()
+	t.Cleanup(s.Close)
+
+	caFile := writeFile(t, t.TempDir(), "ca.pem", cert.cert)
+
+	tt := []struct {
+		desc       string
+		serverName string
+		wantReqErr bool
+	}{
+		{
+			desc:       "IP address",
+			wantReqErr: true,
+		},
+		{
+			desc:       "server name",
+			serverName: "other.example.com",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := New(Options{
+				CAFile:     caFile,
+				ServerName: tc.serverName,
+			})
+			if err != nil {
+				t.Fatalf("error creating config: %s", err)
+			}
+
+			_, err = get(config.TLSConfig(), s.URL)
+			if (err != nil) != tc.wantReqErr {
+				t.Errorf("got request error %v, want error %v", err, tc.wantReqErr)
+			}
+		})
+	}
+}
+
+func get(config *tls.Config, url string) (string, error) {
+	client := &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig:   config,
+			DisableKeepAlives: true,
+		},
+	}
+
+	res, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	return string(body), err
+}
+
+type keyPair struct {
+	cert []byte
+	key  []byte
+}
+
+func newCertificate(t *testing.T, commonName string, dnsNames ...string) keyPair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              dnsNames,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %s", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("error marshalling key: %s", err)
+	}
+
+	return keyPair{
+		cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+
+	fileName := filepath.Join(dir, name)
+	if err := os.WriteFile(fileName, data, 0o600); err != nil {
+		t.Fatalf("error writing %s: %s", name, err)
+	}
+
+	return fileName
+}
+
+// update changes the contents of a file and makes sure the modification time is newer than before.
+func update(t *testing.T, fileName string, data []byte) {
+	t.Helper()
+
+	info, err := os.Stat(fileName)
+	if err != nil {
+		t.Fatalf("error reading %s: %s", fileName, err)
+	}
+
+	if err := os.WriteFile(fileName, data, 0o600); err != nil {
+		t.Fatalf("error writing %s: %s", fileName, err)
+	}
+
+	modTime := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(fileName, modTime, modTime); err != nil {
+		t.Fatalf("error changing time of %s: %s", fileName, err)
+	}
+}
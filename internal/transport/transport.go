@@ -0,0 +1,182 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xperimental/nextcloud-exporter/internal/tlsconfig"
+)
+
+const (
+	unixScheme = "unix://"
+
+	// UnixServerHost is used as host of the HTTP requests when the server URL is a Unix socket.
+	UnixServerHost = "localhost"
+)
+
+var (
+	errProxyScheme  = errors.New("proxy URL needs to use http, https, socks5 or socks5h")
+	errEmptyAddress = errors.New("address can not be empty")
+)
+
+// Options contains the settings used for connecting to a Nextcloud server.
+type Options struct {
+	// TLSConfig is used for HTTPS connections. The default configuration is used if it is nil.
+	TLSConfig *tlsconfig.Config
+	// ProxyURL is the URL of the proxy used for all requests. If it is empty, the proxy is read from
+	// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+	// Hosts overrides the address used for connecting to a host. The keys are host names, optionally with a port.
+	// The values can be a host, a host with port or a Unix socket using the "unix://" prefix.
+	Hosts map[string]string
+}
+
+// ParseProxyURL parses and checks a proxy URL. An empty string results in nil.
+func ParseProxyURL(rawURL string) (*url.URL, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse proxy URL: %w", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return proxyURL, nil
+	default:
+		return nil, errProxyScheme
+	}
+}
+
+// UnixServerURL converts a server URL of the form "unix:///path/to/socket" into an HTTP URL and the address of the socket.
+// The returned address needs to be used in Options.Hosts for UnixServerHost. ok is false for all other URLs.
+func UnixServerURL(serverURL string) (httpURL, address string, ok bool) {
+	if !strings.HasPrefix(serverURL, unixScheme) {
+		return serverURL, "", false
+	}
+
+	return "http://" + UnixServerHost, serverURL, true
+}
+
+// New creates a transport for connecting to a Nextcloud server.
+func New(opts Options) (http.RoundTripper, error) {
+	proxy := http.ProxyFromEnvironment
+	if opts.ProxyURL != "" {
+		proxyURL, err := ParseProxyURL(opts.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	hosts := make(map[string]string, len(opts.Hosts))
+	for host, address := range opts.Hosts {
+		if address == "" || address == unixScheme {
+			return nil, fmt.Errorf("override for %q: %w", host, errEmptyAddress)
+		}
+
+		hosts[strings.ToLower(host)] = address
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	newTransport := func(tlsConfig *tls.Config) *http.Transport {
+		result := http.DefaultTransport.(*http.Transport).Clone()
+		result.Proxy = proxy
+		result.TLSClientConfig = tlsConfig
+		result.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			network, addr = resolve(hosts, network, addr)
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		return result
+	}
+
+	if opts.TLSConfig == nil {
+		return newTransport(nil), nil
+	}
+
+	return &tlsTransport{
+		tlsConfig:    opts.TLSConfig,
+		newTransport: newTransport,
+	}, nil
+}
+
+// tlsTransport creates a new transport when the TLS configuration changes, for example because the CA file
+// has been renewed. Idle connections of the previous transport are closed.
+type tlsTransport struct {
+	tlsConfig    *tlsconfig.Config
+	newTransport func(*tls.Config) *http.Transport
+
+	lock      sync.Mutex
+	config    *tls.Config
+	transport *http.Transport
+}
+
+func (t *tlsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.current().RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport.
+func (t *tlsTransport) CloseIdleConnections() {
+	t.current().CloseIdleConnections()
+}
+
+func (t *tlsTransport) current() *http.Transport {
+	config := t.tlsConfig.TLSConfig()
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if config != t.config {
+		if t.transport != nil {
+			t.transport.CloseIdleConnections()
+		}
+
+		t.transport = t.newTransport(config)
+		t.config = config
+	}
+
+	return t.transport
+}
+
+// resolve returns the network and address used for connecting to addr, taking the overrides into account.
+func resolve(hosts map[string]string, network, addr string) (string, string) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return network, addr
+	}
+
+	target, ok := hosts[strings.ToLower(addr)]
+	if !ok {
+		target, ok = hosts[strings.ToLower(host)]
+	}
+
+	if !ok {
+		return network, addr
+	}
+
+	if strings.HasPrefix(target, unixScheme) {
+		return "unix", strings.TrimPrefix(target, unixScheme)
+	}
+
+	if _, _, err := net.SplitHostPort(target); err == nil {
+		return network, target
+	}
+
+	return network, net.JoinHostPort(target, port)
+}
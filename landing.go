@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/exporter-toolkit/web"
+
+	"github.com/xperimental/nextcloud-exporter/internal/config"
+)
+
+// newLandingPage creates the page shown on "/", which contains an overview of the configuration and links to the other endpoints.
+func newLandingPage(cfg config.Config) (http.Handler, error) {
+	links := []web.LandingLinks{
+		{
+			Address: "/metrics",
+			Text:    "Metrics",
+		},
+	}
+
+	if len(cfg.Modules) > 0 {
+		links = append(links, web.LandingLinks{
+			Address:     "/probe",
+			Text:        "Probe",
+			Description: "metrics of a configured target, selected using the target parameter",
+		})
+	}
+
+	links = append(links,
+		web.LandingLinks{
+			Address: "/-/healthy",
+			Text:    "Health",
+		},
+		web.LandingLinks{
+			Address: "/-/ready",
+			Text:    "Readiness",
+		},
+	)
+
+	return web.NewLandingPage(web.LandingConfig{
+		Name:        "Nextcloud Exporter",
+		Description: "Prometheus exporter for Nextcloud servers",
+		Version:     Version,
+		Links:       links,
+		ExtraHTML:   landingDetails(cfg),
+		Profiling:   "false",
+	})
+}
+
+func landingDetails(cfg config.Config) string {
+	server := cfg.ServerURL
+	if server == "" {
+		server = "none"
+	}
+
+	rows := [][2]string{
+		{"Commit", GitCommit},
+		{"Server", server},
+	}
+
+	if cfg.ServerURL != "" {
+		rows = append(rows,
+			[2]string{"Apps metrics", enabledText(cfg.Info.Apps)},
+			[2]string{"App versions", enabledText(cfg.Info.AppVersions)},
+			[2]string{"Update metrics", enabledText(cfg.Info.Update)},
+			[2]string{"Status endpoint", enabledText(cfg.Status.Enabled)},
+		)
+	}
+
+	if len(cfg.Modules) > 0 {
+		targets := make([]string, 0, len(cfg.Modules))
+		for name := range cfg.Modules {
+			targets = append(targets, name)
+		}
+		sort.Strings(targets)
+
+		rows = append(rows, [2]string{"Probe targets", strings.Join(targets, ", ")})
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<div><table>")
+	for _, row := range rows {
+		fmt.Fprintf(&sb, "<tr><th>%s</th><td>%s</td></tr>", html.EscapeString(row[0]), html.EscapeString(row[1]))
+	}
+	sb.WriteString("</table></div>")
+
+	return sb.String()
+}
+
+func enabledText(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+
+	return "disabled"
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/xperimental/nextcloud-exporter/internal/config"
+	"github.com/xperimental/nextcloud-exporter/internal/login"
+)
+
+var errLoginNoServer = errors.New("need to specify --server for login")
+
+// runLogin creates an app password using the interactive login and outputs the credentials according to the login options.
+// The credentials are written to stdout or files, but never to the log.
+func runLogin(cfg config.Config, userAgent string) error {
+	if cfg.ServerURL == "" {
+		return errLoginNoServer
+	}
+
+	httpTransport, err := newTransport(cfg.TLSOptions(), cfg.TransportOptions())
+	if err != nil {
+		return fmt.Errorf("error creating transport: %w", err)
+	}
+	loginClient := login.Init(log, userAgent, cfg.ServerURL, httpTransport)
+
+	var qrCode io.Writer
+	if cfg.Login.QRCode {
+		qrCode = os.Stderr
+	}
+
+	log.Infof("Starting interactive login on: %s", cfg.ServerURL)
+	result, err := loginClient.StartInteractive(qrCode)
+	if err != nil {
+		return fmt.Errorf("error during login: %w", err)
+	}
+	log.Infof("Logged in as %q.", result.Username)
+
+	password := result.Password
+	if cfg.Login.PasswordFile != "" {
+		fileName, err := filepath.Abs(cfg.Login.PasswordFile)
+		if err != nil {
+			return err
+		}
+
+		if err := login.WritePasswordFile(fileName, result.Password); err != nil {
+			return fmt.Errorf("error writing password file: %w", err)
+		}
+		log.Infof("Wrote app password to %s", fileName)
+		password = "@" + fileName
+	}
+
+	if cfg.Login.ConfigFile != "" {
+		if err := login.UpdateConfigFile(cfg.Login.ConfigFile, result.Username, password); err != nil {
+			return fmt.Errorf("error updating configuration file: %w", err)
+		}
+		log.Infof("Updated credentials in %s", cfg.Login.ConfigFile)
+	}
+
+	switch {
+	case cfg.Login.JSON:
+		// the password is only shown, if it was not written to a file
+		output := result
+		output.Password = password
+		return json.NewEncoder(os.Stdout).Encode(output)
+	case cfg.Login.PasswordFile == "" && cfg.Login.ConfigFile == "":
+		fmt.Printf("Username: %s\nPassword: %s\n", result.Username, result.Password)
+	}
+
+	return nil
+}
@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/exporter-toolkit/web"
 	"github.com/sirupsen/logrus"
-	"github.com/xperimental/nextcloud-exporter/internal/client"
 	"github.com/xperimental/nextcloud-exporter/internal/config"
-	"github.com/xperimental/nextcloud-exporter/internal/login"
 	"github.com/xperimental/nextcloud-exporter/internal/metrics"
-	"github.com/xperimental/nextcloud-exporter/serverinfo"
 )
 
 var (
@@ -33,6 +38,9 @@ var (
 	}
 )
 
+// shutdownTimeout is the maximum time to wait for running requests when shutting down.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	cfg, err := config.Get()
 	if err != nil {
@@ -52,15 +60,18 @@ func main() {
 	userAgent := fmt.Sprintf("nextcloud-exporter/%s", Version)
 
 	if cfg.RunMode == config.RunModeLogin {
-		if cfg.ServerURL == "" {
-			log.Fatalf("Need to specify --server for login.")
+		if err := runLogin(cfg, userAgent); err != nil {
+			log.Fatalf("Login failed: %s", err)
 		}
-		loginClient := login.Init(log, userAgent, cfg.ServerURL, cfg.TLSSkipVerify)
+		return
+	}
 
-		log.Infof("Starting interactive login on: %s", cfg.ServerURL)
-		if err := loginClient.StartInteractive(); err != nil {
-			log.Fatalf("Error during login: %s", err)
+	if cfg.RunMode == config.RunModeCheckConfig {
+		if err := checkConfig(cfg, os.Stdout); err != nil {
+			log.Fatalf("Configuration check failed: %s", err)
 		}
+
+		log.Info("Configuration is valid.")
 		return
 	}
 
@@ -68,30 +79,92 @@ func main() {
 		log.Fatalf("Invalid configuration: %s", err)
 	}
 
-	if cfg.AuthToken == "" {
-		log.Infof("Nextcloud server: %s User: %s", cfg.ServerURL, cfg.Username)
-	} else {
-		log.Infof("Nextcloud server: %s Authentication using token.", cfg.ServerURL)
+	if err := metrics.RegisterInfoMetric(Version, GitCommit); err != nil {
+		log.Fatalf("Failed to register info metric: %s", err)
+	}
+
+	reloadMetrics, err := metrics.RegisterReloadMetrics()
+	if err != nil {
+		log.Fatalf("Failed to register reload metrics: %s", err)
 	}
 
-	infoURL := serverinfo.InfoURL(cfg.ServerURL, !cfg.Info.Apps, !cfg.Info.Update)
+	reloader, err := newReloader(cfg, userAgent, reloadMetrics)
+	if err != nil {
+		log.Fatalf("Failed to create exporter: %s", err)
+	}
 
-	if cfg.TLSSkipVerify {
-		log.Warn("HTTPS certificate verification is disabled.")
+	http.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, reloader.handle(func(e *exporter) http.Handler { return e.metricsHandler })))
+	http.Handle("/probe", reloader.handle(func(e *exporter) http.Handler { return e.probeHandler }))
+	http.Handle("/-/healthy", metrics.NewHealthyHandler())
+	http.Handle("/-/ready", reloader.handle(func(e *exporter) http.Handler { return e.readyHandler }))
+	if cfg.WebEnableLifecycle {
+		http.Handle("/-/reload", reloader)
 	}
+	http.Handle("/", reloader.handle(func(e *exporter) http.Handler { return e.landingPage }))
 
-	infoClient := client.New(infoURL, cfg.Username, cfg.Password, cfg.AuthToken, cfg.Timeout, userAgent, cfg.TLSSkipVerify)
-	if err := metrics.RegisterCollector(log, infoClient, cfg.Info.Apps, cfg.Info.Update); err != nil {
-		log.Fatalf("Failed to register collector: %s", err)
+	go reloadOnSignal(reloader)
+
+	listenAddresses := []string{cfg.ListenAddr}
+	systemdSocket := false
+	webFlags := &web.FlagConfig{
+		WebListenAddresses: &listenAddresses,
+		WebSystemdSocket:   &systemdSocket,
+		WebConfigFile:      &cfg.WebConfigFile,
 	}
 
-	if err := metrics.RegisterInfoMetric(Version, GitCommit); err != nil {
-		log.Fatalf("Failed to register info metric: %s", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- web.ListenAndServe(server, webFlags, newServerLogger())
+	}()
+
+	select {
+	case err := <-serverErr:
+		log.Fatalf("Error running server: %s", err)
+	case <-ctx.Done():
+	}
+
+	log.Info("Shutting down, waiting for running requests to finish...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("Error during shutdown: %s", err)
+	}
+	reloader.current().stop()
+}
+
+// reloadOnSignal reloads the configuration every time the process receives SIGHUP.
+func reloadOnSignal(r *reloader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		if err := r.reload(); err != nil {
+			log.Errorf("Failed to reload configuration: %s", err)
+			continue
+		}
+
+		log.Info("Reloaded configuration.")
 	}
+}
+
+// newServerLogger creates a logger for the HTTP server, which uses the same output as the main logger.
+func newServerLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(log.Out, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
 
-	http.Handle("/metrics", promhttp.Handler())
-	http.Handle("/", http.RedirectHandler("/metrics", http.StatusFound))
+			if attr.Key == slog.LevelKey && len(groups) == 0 {
+				return slog.String(slog.LevelKey, strings.ToLower(attr.Value.String()))
+			}
 
-	log.Infof("Listen on %s...", cfg.ListenAddr)
-	log.Fatal(http.ListenAndServe(cfg.ListenAddr, nil))
+			return attr
+		},
+	}))
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xperimental/nextcloud-exporter/internal/config"
+	"github.com/xperimental/nextcloud-exporter/internal/metrics"
+)
+
+// reloader keeps the exporter created from the current configuration and replaces it when the configuration is reloaded.
+type reloader struct {
+	userAgent string
+	metrics   *metrics.ReloadMetrics
+
+	lock     sync.Mutex
+	cfg      config.Config
+	exporter atomic.Pointer[exporter]
+}
+
+func newReloader(cfg config.Config, userAgent string, reloadMetrics *metrics.ReloadMetrics) (*reloader, error) {
+	e, err := newExporter(cfg, userAgent, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reloader{
+		userAgent: userAgent,
+		metrics:   reloadMetrics,
+		cfg:       cfg,
+	}
+	r.exporter.Store(e)
+
+	return r, nil
+}
+
+// reload reads the configuration again and replaces the exporter. The previous exporter is kept, if the new configuration is invalid.
+// Requests which are already running finish using the previous exporter.
+func (r *reloader) reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	err := r.apply()
+	r.metrics.Observe(err == nil, time.Now())
+	return err
+}
+
+func (r *reloader) apply() error {
+	cfg, err := config.Get()
+	if err != nil {
+		return fmt.Errorf("error loading configuration: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.ListenAddr != r.cfg.ListenAddr || cfg.WebConfigFile != r.cfg.WebConfigFile || cfg.WebEnableLifecycle != r.cfg.WebEnableLifecycle {
+		log.Warn("Changes to the listen address, web configuration file and lifecycle endpoint need a restart.")
+	}
+
+	// the last successful contact is only kept while the server stays the same
+	var lastSuccess time.Time
+	if cfg.ServerURL == r.cfg.ServerURL {
+		lastSuccess = r.current().lastSuccess()
+	}
+
+	e, err := newExporter(cfg, r.userAgent, lastSuccess)
+	if err != nil {
+		return err
+	}
+
+	previous := r.exporter.Swap(e)
+	previous.stop()
+	r.cfg = cfg
+
+	return nil
+}
+
+func (r *reloader) current() *exporter {
+	return r.exporter.Load()
+}
+
+func (r *reloader) handle(handler func(e *exporter) http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		handler(r.current()).ServeHTTP(w, req)
+	})
+}
+
+// ServeHTTP reloads the configuration on POST requests.
+func (r *reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST requests allowed.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.reload(); err != nil {
+		log.Errorf("Failed to reload configuration: %s", err)
+		http.Error(w, "Failed to reload configuration, see log for details.", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("Reloaded configuration.")
+	fmt.Fprintln(w, "Reloaded configuration.")
+}
@@ -10,6 +10,7 @@ func TestParseJSON(t *testing.T) {
 		"info.json",
 		"negative-space.json",
 		"na-values.json",
+		"unavailable-memory.json",
 		"nc22.json",
 		"large-freespace.json",
 	}
@@ -5,6 +5,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // ServerInfo contains the complete data received from the server.
@@ -35,41 +36,80 @@ type Nextcloud struct {
 }
 
 // System contains nextcloud configuration and system information.
+// CPULoad contains the load average of the host for the last 1, 5 and 15 minutes and is nil if the load is not reported.
 type System struct {
-	Version             string  `json:"version"`
-	Theme               string  `json:"theme"`
-	EnableAvatars       bool    `json:"enable_avatars"`
-	EnablePreviews      bool    `json:"enable_previews"`
-	MemcacheLocal       string  `json:"memcache.local"`
-	MemcacheDistributed string  `json:"memcache.distributed"`
-	MemcacheLocking     string  `json:"memcache.locking"`
-	FilelockingEnabled  bool    `json:"filelocking.enabled"`
-	Debug               bool    `json:"debug"`
-	FreeSpace           float64 `json:"freespace"`
-	Apps                Apps    `json:"apps"`
-	Update              Update  `json:"update"`
-}
-
-const boolYes = "yes"
+	Version             string     `json:"version"`
+	Theme               string     `json:"theme"`
+	EnableAvatars       bool       `json:"enable_avatars"`
+	EnablePreviews      bool       `json:"enable_previews"`
+	MemcacheLocal       string     `json:"memcache.local"`
+	MemcacheDistributed string     `json:"memcache.distributed"`
+	MemcacheLocking     string     `json:"memcache.locking"`
+	FilelockingEnabled  bool       `json:"filelocking.enabled"`
+	Debug               bool       `json:"debug"`
+	FreeSpace           float64    `json:"freespace"`
+	CPULoad             []float64  `json:"cpuload"`
+	Memory              MemoryInfo `json:"-"`
+	Swap                MemoryInfo `json:"-"`
+	Apps                Apps       `json:"apps"`
+	Update              Update     `json:"update"`
+}
+
+// MemoryInfo contains information about memory or swap of the host running Nextcloud.
+type MemoryInfo struct {
+	// Available is false, if the server did not report the values.
+	Available bool
+	// Total contains the total memory in bytes.
+	Total uint64
+	// Free contains the free memory in bytes.
+	Free uint64
+}
+
+const (
+	boolYes          = "yes"
+	valueNA          = "N/A"
+	bytesPerKilobyte = 1024
+)
 
 func (s *System) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	var raw struct {
-		Version             string  `xml:"version"`
-		Theme               string  `xml:"theme"`
-		EnableAvatars       string  `xml:"enable_avatars"`
-		EnablePreviews      string  `xml:"enable_previews"`
-		MemcacheLocal       string  `xml:"memcache.local"`
-		MemcacheDistributed string  `xml:"memcache.distributed"`
-		MemcacheLocking     string  `xml:"memcache.locking"`
-		FilelockingEnabled  string  `xml:"filelocking.enabled"`
-		Debug               string  `xml:"debug"`
-		FreeSpace           float64 `xml:"freespace"`
-		Apps                Apps    `xml:"apps"`
-		Update              Update  `xml:"update"`
+		Version             string   `xml:"version"`
+		Theme               string   `xml:"theme"`
+		EnableAvatars       string   `xml:"enable_avatars"`
+		EnablePreviews      string   `xml:"enable_previews"`
+		MemcacheLocal       string   `xml:"memcache.local"`
+		MemcacheDistributed string   `xml:"memcache.distributed"`
+		MemcacheLocking     string   `xml:"memcache.locking"`
+		FilelockingEnabled  string   `xml:"filelocking.enabled"`
+		Debug               string   `xml:"debug"`
+		FreeSpace           float64  `xml:"freespace"`
+		CPULoad             []string `xml:"cpuload>element"`
+		MemTotal            string   `xml:"mem_total"`
+		MemFree             string   `xml:"mem_free"`
+		SwapTotal           string   `xml:"swap_total"`
+		SwapFree            string   `xml:"swap_free"`
+		Apps                Apps     `xml:"apps"`
+		Update              Update   `xml:"update"`
 	}
 	if err := d.DecodeElement(&raw, &start); err != nil {
 		return err
 	}
+
+	cpuLoad, err := parseCPULoad(raw.CPULoad)
+	if err != nil {
+		return err
+	}
+
+	memory, err := parseMemoryInfo("mem", raw.MemTotal, raw.MemFree)
+	if err != nil {
+		return err
+	}
+
+	swap, err := parseMemoryInfo("swap", raw.SwapTotal, raw.SwapFree)
+	if err != nil {
+		return err
+	}
+
 	s.Version = raw.Version
 	s.Theme = raw.Theme
 	s.EnableAvatars = raw.EnableAvatars == boolYes
@@ -80,6 +120,9 @@ func (s *System) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	s.FilelockingEnabled = raw.FilelockingEnabled == boolYes
 	s.Debug = raw.Debug == boolYes
 	s.FreeSpace = raw.FreeSpace
+	s.CPULoad = cpuLoad
+	s.Memory = memory
+	s.Swap = swap
 	s.Apps = raw.Apps
 	s.Update = raw.Update
 	return nil
@@ -87,22 +130,51 @@ func (s *System) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 
 func (s *System) UnmarshalJSON(data []byte) error {
 	var raw struct {
-		Version             string  `json:"version"`
-		Theme               string  `json:"theme"`
-		EnableAvatars       string  `json:"enable_avatars"`
-		EnablePreviews      string  `json:"enable_previews"`
-		MemcacheLocal       string  `json:"memcache.local"`
-		MemcacheDistributed string  `json:"memcache.distributed"`
-		MemcacheLocking     string  `json:"memcache.locking"`
-		FilelockingEnabled  string  `json:"filelocking.enabled"`
-		Debug               string  `json:"debug"`
-		FreeSpace           float64 `json:"freespace"`
-		Apps                Apps    `json:"apps"`
-		Update              Update  `json:"update"`
+		Version             string      `json:"version"`
+		Theme               string      `json:"theme"`
+		EnableAvatars       string      `json:"enable_avatars"`
+		EnablePreviews      string      `json:"enable_previews"`
+		MemcacheLocal       string      `json:"memcache.local"`
+		MemcacheDistributed string      `json:"memcache.distributed"`
+		MemcacheLocking     string      `json:"memcache.locking"`
+		FilelockingEnabled  string      `json:"filelocking.enabled"`
+		Debug               string      `json:"debug"`
+		FreeSpace           float64     `json:"freespace"`
+		CPULoad             interface{} `json:"cpuload"`
+		MemTotal            interface{} `json:"mem_total"`
+		MemFree             interface{} `json:"mem_free"`
+		SwapTotal           interface{} `json:"swap_total"`
+		SwapFree            interface{} `json:"swap_free"`
+		Apps                Apps        `json:"apps"`
+		Update              Update      `json:"update"`
 	}
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return err
 	}
+
+	var cpuLoad []float64
+	if rawLoad, ok := raw.CPULoad.([]interface{}); ok {
+		cpuLoad = make([]float64, 0, len(rawLoad))
+		for _, rawValue := range rawLoad {
+			value, ok := rawValue.(float64)
+			if !ok {
+				return fmt.Errorf("unexpected type for system.cpuload: %T", rawValue)
+			}
+
+			cpuLoad = append(cpuLoad, value)
+		}
+	}
+
+	memory, err := parseMemoryInfo("mem", raw.MemTotal, raw.MemFree)
+	if err != nil {
+		return err
+	}
+
+	swap, err := parseMemoryInfo("swap", raw.SwapTotal, raw.SwapFree)
+	if err != nil {
+		return err
+	}
+
 	s.Version = raw.Version
 	s.Theme = raw.Theme
 	s.EnableAvatars = raw.EnableAvatars == boolYes
@@ -113,15 +185,116 @@ func (s *System) UnmarshalJSON(data []byte) error {
 	s.FilelockingEnabled = raw.FilelockingEnabled == boolYes
 	s.Debug = raw.Debug == boolYes
 	s.FreeSpace = raw.FreeSpace
+	s.CPULoad = cpuLoad
+	s.Memory = memory
+	s.Swap = swap
 	s.Apps = raw.Apps
 	s.Update = raw.Update
 	return nil
 }
 
+func parseCPULoad(rawLoad []string) ([]float64, error) {
+	if len(rawLoad) == 0 {
+		return nil, nil
+	}
+
+	result := make([]float64, 0, len(rawLoad))
+	for _, rawValue := range rawLoad {
+		value, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil {
+			return nil, fmt.Errorf("can not parse system.cpuload %q: %w", rawValue, err)
+		}
+
+		result = append(result, value)
+	}
+
+	return result, nil
+}
+
+func parseMemoryInfo(prefix string, rawTotal, rawFree interface{}) (MemoryInfo, error) {
+	total, totalOk, err := parseKilobytes(prefix+"_total", rawTotal)
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+
+	free, freeOk, err := parseKilobytes(prefix+"_free", rawFree)
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+
+	if !totalOk || !freeOk {
+		return MemoryInfo{}, nil
+	}
+
+	return MemoryInfo{
+		Available: true,
+		Total:     total,
+		Free:      free,
+	}, nil
+}
+
+// parseKilobytes parses a value in kilobytes and returns it in bytes.
+// The boolean result is false, if the value is not available. Nextcloud reports -1, if it can not read the value.
+func parseKilobytes(name string, raw interface{}) (uint64, bool, error) {
+	switch value := raw.(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		if value < 0 {
+			return 0, false, nil
+		}
+
+		return uint64(value) * bytesPerKilobyte, true, nil
+	case string:
+		if value == "" || value == valueNA {
+			return 0, false, nil
+		}
+
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("can not parse system.%s %q: %w", name, value, err)
+		}
+
+		if parsed < 0 {
+			return 0, false, nil
+		}
+
+		return uint64(parsed) * bytesPerKilobyte, true, nil
+	default:
+		return 0, false, fmt.Errorf("unexpected type for system.%s: %T", name, value)
+	}
+}
+
 // Apps contains information about installed apps and updates.
+// Updates maps the ID of apps with available updates to the available version.
 type Apps struct {
-	Installed        uint `json:"num_installed"`
-	AvailableUpdates uint `json:"num_updates_available"`
+	Installed        uint              `json:"num_installed"`
+	AvailableUpdates uint              `json:"num_updates_available"`
+	Updates          map[string]string `json:"app_updates"`
+}
+
+func (a *Apps) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Installed        uint            `json:"num_installed"`
+		AvailableUpdates uint            `json:"num_updates_available"`
+		Updates          json.RawMessage `json:"app_updates"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	a.Installed = raw.Installed
+	a.AvailableUpdates = raw.AvailableUpdates
+	a.Updates = nil
+
+	// PHP encodes an empty map as an empty array.
+	if isJSONObject(raw.Updates) {
+		if err := json.Unmarshal(raw.Updates, &a.Updates); err != nil {
+			return fmt.Errorf("can not parse apps.app_updates: %w", err)
+		}
+	}
+
+	return nil
 }
 
 // Update contains information about Nextcloud system updates.
@@ -142,21 +315,106 @@ type Storage struct {
 
 // Shares contains information about nextcloud shares.
 type Shares struct {
-	SharesTotal          uint `json:"num_shares"`
-	SharesUser           uint `json:"num_shares_user"`
-	SharesGroups         uint `json:"num_shares_groups"`
-	SharesLink           uint `json:"num_shares_link"`
-	SharesLinkNoPassword uint `json:"num_shares_link_no_password"`
-	SharesMail           uint `json:"num_shares_mail"`
-	SharesRoom           uint `json:"num_shares_room"`
-	FedSent              uint `json:"num_fed_shares_sent"`
-	FedReceived          uint `json:"num_fed_shares_received"`
-	// <permissions_0_1>2</permissions_0_1>
-	// <permissions_3_1>4</permissions_3_1>
-	// <permissions_0_15>2</permissions_0_15>
-	// <permissions_3_15>2</permissions_3_15>
-	// <permissions_0_31>3</permissions_0_31>
-	// <permissions_1_31>1</permissions_1_31>
+	SharesTotal          uint                         `json:"num_shares"`
+	SharesUser           uint                         `json:"num_shares_user"`
+	SharesGroups         uint                         `json:"num_shares_groups"`
+	SharesLink           uint                         `json:"num_shares_link"`
+	SharesLinkNoPassword uint                         `json:"num_shares_link_no_password"`
+	SharesMail           uint                         `json:"num_shares_mail"`
+	SharesRoom           uint                         `json:"num_shares_room"`
+	FedSent              uint                         `json:"num_fed_shares_sent"`
+	FedReceived          uint                         `json:"num_fed_shares_received"`
+	Permissions          map[SharePermissionsKey]uint `json:"-"`
+}
+
+// SharePermissionsKey identifies a combination of share type and permission bits.
+type SharePermissionsKey struct {
+	ShareType   int
+	Permissions int
+}
+
+const sharePermissionsPrefix = "permissions_"
+
+// UnmarshalJSON decodes the share statistics. The number of shares by share type and permissions is sent by Nextcloud
+// using keys like "permissions_<shareType>_<permissions>", which are collected into the Permissions map.
+// Keys and values which can not be parsed are skipped, so that they do not prevent reading the other information.
+func (s *Shares) UnmarshalJSON(data []byte) error {
+	type rawShares Shares
+	var raw rawShares
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	for key, rawValue := range fields {
+		if !strings.HasPrefix(key, sharePermissionsPrefix) {
+			continue
+		}
+
+		permissionsKey, err := parseSharePermissionsKey(key)
+		if err != nil {
+			continue
+		}
+
+		count, err := parseShareCount(key, rawValue)
+		if err != nil {
+			continue
+		}
+
+		if raw.Permissions == nil {
+			raw.Permissions = make(map[SharePermissionsKey]uint)
+		}
+		raw.Permissions[permissionsKey] = count
+	}
+
+	*s = Shares(raw)
+	return nil
+}
+
+func parseSharePermissionsKey(key string) (SharePermissionsKey, error) {
+	tokens := strings.Split(strings.TrimPrefix(key, sharePermissionsPrefix), "_")
+	if len(tokens) != 2 {
+		return SharePermissionsKey{}, fmt.Errorf("unexpected format for shares.%s", key)
+	}
+
+	shareType, err := strconv.Atoi(tokens[0])
+	if err != nil {
+		return SharePermissionsKey{}, fmt.Errorf("can not parse share type of shares.%s: %w", key, err)
+	}
+
+	permissions, err := strconv.Atoi(tokens[1])
+	if err != nil {
+		return SharePermissionsKey{}, fmt.Errorf("can not parse permissions of shares.%s: %w", key, err)
+	}
+
+	return SharePermissionsKey{
+		ShareType:   shareType,
+		Permissions: permissions,
+	}, nil
+}
+
+func parseShareCount(key string, rawValue interface{}) (uint, error) {
+	switch value := rawValue.(type) {
+	case float64:
+		if value < 0 {
+			return 0, fmt.Errorf("negative value for shares.%s: %f", key, value)
+		}
+
+		return uint(value), nil
+	case string:
+		parsed, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return 0, fmt.Errorf("can not parse shares.%s %q: %w", key, value, err)
+		}
+
+		return uint(parsed), nil
+	default:
+		return 0, fmt.Errorf("unexpected type for shares.%s: %T", key, value)
+	}
 }
 
 // Server contains information about the servers running nextcloud.
@@ -168,10 +426,74 @@ type Server struct {
 
 // PHP contains information about the PHP installation.
 type PHP struct {
-	Version           string `json:"version"`
-	MemoryLimit       int64  `json:"memory_limit"`
-	MaxExecutionTime  uint   `json:"max_execution_time"`
-	UploadMaxFilesize int64  `json:"upload_max_filesize"`
+	Version           string  `json:"version"`
+	MemoryLimit       int64   `json:"memory_limit"`
+	MaxExecutionTime  uint    `json:"max_execution_time"`
+	UploadMaxFilesize int64   `json:"upload_max_filesize"`
+	OPcache           OPcache `json:"opcache"`
+	APCu              APCu    `json:"apcu"`
+}
+
+// OPcache contains the status of the PHP OPcache.
+type OPcache struct {
+	Enabled           bool                   `json:"opcache_enabled"`
+	CacheFull         bool                   `json:"cache_full"`
+	RestartPending    bool                   `json:"restart_pending"`
+	RestartInProgress bool                   `json:"restart_in_progress"`
+	MemoryUsage       OPcacheMemoryUsage     `json:"memory_usage"`
+	InternedStrings   OPcacheInternedStrings `json:"interned_strings_usage"`
+	Statistics        OPcacheStatistics      `json:"opcache_statistics"`
+}
+
+// UnmarshalJSON decodes the OPcache status. Nextcloud does not send an object when the OPcache is disabled,
+// in that case the result is an OPcache with Enabled set to false.
+func (o *OPcache) UnmarshalJSON(data []byte) error {
+	if !isJSONObject(data) {
+		*o = OPcache{}
+		return nil
+	}
+
+	type rawOPcache OPcache
+	var raw rawOPcache
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*o = OPcache(raw)
+	return nil
+}
+
+// OPcacheMemoryUsage contains information about the shared memory used by the OPcache.
+type OPcacheMemoryUsage struct {
+	UsedMemory              uint64  `json:"used_memory"`
+	FreeMemory              uint64  `json:"free_memory"`
+	WastedMemory            uint64  `json:"wasted_memory"`
+	CurrentWastedPercentage float64 `json:"current_wasted_percentage"`
+}
+
+// OPcacheInternedStrings contains information about the interned strings buffer of the OPcache.
+type OPcacheInternedStrings struct {
+	BufferSize      uint64 `json:"buffer_size"`
+	UsedMemory      uint64 `json:"used_memory"`
+	FreeMemory      uint64 `json:"free_memory"`
+	NumberOfStrings uint64 `json:"number_of_strings"`
+}
+
+// OPcacheStatistics contains the usage statistics of the OPcache.
+type OPcacheStatistics struct {
+	CachedScripts      uint64  `json:"num_cached_scripts"`
+	CachedKeys         uint64  `json:"num_cached_keys"`
+	MaxCachedKeys      uint64  `json:"max_cached_keys"`
+	Hits               uint64  `json:"hits"`
+	StartTime          int64   `json:"start_time"`
+	LastRestartTime    int64   `json:"last_restart_time"`
+	OOMRestarts        uint64  `json:"oom_restarts"`
+	HashRestarts       uint64  `json:"hash_restarts"`
+	ManualRestarts     uint64  `json:"manual_restarts"`
+	Misses             uint64  `json:"misses"`
+	BlacklistMisses    uint64  `json:"blacklist_misses"`
+	BlacklistMissRatio float64 `json:"blacklist_miss_ratio"`
+	HitRate            float64 `json:"opcache_hit_rate"`
 }
 
 // Database contains information about the database used by nextcloud.
@@ -214,6 +536,69 @@ func (d *Database) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// APCu contains the status of the APCu user cache.
+type APCu struct {
+	// Available is true, if the server reported information about APCu.
+	Available bool      `json:"-"`
+	Cache     APCuCache `json:"cache"`
+	SMA       APCuSMA   `json:"sma"`
+}
+
+// UnmarshalJSON decodes the APCu status. Nextcloud does not send an object when APCu is not installed,
+// in that case the result is an APCu with Available set to false.
+func (a *APCu) UnmarshalJSON(data []byte) error {
+	if !isJSONObject(data) {
+		*a = APCu{}
+		return nil
+	}
+
+	type rawAPCu APCu
+	var raw rawAPCu
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*a = APCu(raw)
+	a.Available = true
+	return nil
+}
+
+// APCuCache contains the statistics of the APCu cache.
+type APCuCache struct {
+	Slots      uint64 `json:"num_slots"`
+	TTL        uint64 `json:"ttl"`
+	Hits       uint64 `json:"num_hits"`
+	Misses     uint64 `json:"num_misses"`
+	Inserts    uint64 `json:"num_inserts"`
+	Entries    uint64 `json:"num_entries"`
+	Expunges   uint64 `json:"expunges"`
+	StartTime  int64  `json:"start_time"`
+	MemSize    uint64 `json:"mem_size"`
+	MemoryType string `json:"memory_type"`
+}
+
+// APCuSMA contains information about the shared memory allocator of APCu.
+type APCuSMA struct {
+	Segments     uint64 `json:"num_seg"`
+	SegmentSize  uint64 `json:"seg_size"`
+	AvailableMem uint64 `json:"avail_mem"`
+}
+
+func isJSONObject(data []byte) bool {
+	for _, b := range data {
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{':
+			return true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
 // ActiveUsers contains statistics about the active users.
 type ActiveUsers struct {
 	Last5Minutes uint `json:"last5minutes"`
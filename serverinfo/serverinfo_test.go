@@ -0,0 +1,483 @@
+package serverinfo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/xperimental/nextcloud-exporter/internal/testutil"
+)
+
+func TestOPcacheUnmarshalJSON(t *testing.T) {
+	tt := []struct {
+		desc        string
+		input       string
+		wantOPcache OPcache
+	}{
+		{
+			desc: "enabled",
+			input: `{
+  "opcache_enabled": true,
+  "cache_full": false,
+  "memory_usage": {
+    "used_memory": 3928244,
+    "free_memory": 9490738,
+    "wasted_memory": 2789,
+    "current_wasted_percentage": 0.020
+  },
+  "interned_strings_usage": {
+    "buffer_size": 629100,
+    "used_memory": 489804,
+    "free_memory": 139296,
+    "number_of_strings": 7795
+  },
+  "opcache_statistics": {
+    "num_cached_scripts": 209,
+    "num_cached_keys": 399,
+    "max_cached_keys": 1622,
+    "hits": 391187,
+    "start_time": 1634933931,
+    "oom_restarts": 1,
+    "hash_restarts": 2,
+    "manual_restarts": 3,
+    "misses": 210,
+    "opcache_hit_rate": 99.994
+  }
+}`,
+			wantOPcache: OPcache{
+				Enabled: true,
+				MemoryUsage: OPcacheMemoryUsage{
+					UsedMemory:              3928244,
+					FreeMemory:              9490738,
+					WastedMemory:            2789,
+					CurrentWastedPercentage: 0.02,
+				},
+				InternedStrings: OPcacheInternedStrings{
+					BufferSize:      629100,
+					UsedMemory:      489804,
+					FreeMemory:      139296,
+					NumberOfStrings: 7795,
+				},
+				Statistics: OPcacheStatistics{
+					CachedScripts:  209,
+					CachedKeys:     399,
+					MaxCachedKeys:  1622,
+					Hits:           391187,
+					StartTime:      1634933931,
+					OOMRestarts:    1,
+					HashRestarts:   2,
+					ManualRestarts: 3,
+					Misses:         210,
+					HitRate:        99.994,
+				},
+			},
+		},
+		{
+			desc:        "disabled",
+			input:       `false`,
+			wantOPcache: OPcache{},
+		},
+		{
+			desc:        "empty array",
+			input:       `[]`,
+			wantOPcache: OPcache{},
+		},
+		{
+			desc:        "not available",
+			input:       `"N/A"`,
+			wantOPcache: OPcache{},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var opcache OPcache
+			if err := json.Unmarshal([]byte(tc.input), &opcache); err != nil {
+				t.Fatalf("got error %q", err)
+			}
+
+			if diff := cmp.Diff(opcache, tc.wantOPcache); diff != "" {
+				t.Errorf("opcache differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAPCuUnmarshalJSON(t *testing.T) {
+	tt := []struct {
+		desc     string
+		input    string
+		wantAPCu APCu
+	}{
+		{
+			desc: "available",
+			input: `{
+  "cache": {
+    "num_slots": 4099,
+    "ttl": 0,
+    "num_hits": 175992,
+    "num_misses": 1948,
+    "num_inserts": 2009,
+    "num_entries": 599,
+    "expunges": 1,
+    "start_time": 1627817478,
+    "mem_size": 295024,
+    "memory_type": "mmap"
+  },
+  "sma": {
+    "num_seg": 1,
+    "seg_size": 33554312,
+    "avail_mem": 33206176
+  }
+}`,
+			wantAPCu: APCu{
+				Available: true,
+				Cache: APCuCache{
+					Slots:      4099,
+					Hits:       175992,
+					Misses:     1948,
+					Inserts:    2009,
+					Entries:    599,
+					Expunges:   1,
+					StartTime:  1627817478,
+					MemSize:    295024,
+					MemoryType: "mmap",
+				},
+				SMA: APCuSMA{
+					Segments:     1,
+					SegmentSize:  33554312,
+					AvailableMem: 33206176,
+				},
+			},
+		},
+		{
+			desc:     "not available",
+			input:    `"N/A"`,
+			wantAPCu: APCu{},
+		},
+		{
+			desc:     "not installed",
+			input:    `false`,
+			wantAPCu: APCu{},
+		},
+		{
+			desc:     "empty array",
+			input:    `[]`,
+			wantAPCu: APCu{},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var apcu APCu
+			if err := json.Unmarshal([]byte(tc.input), &apcu); err != nil {
+				t.Fatalf("got error %q", err)
+			}
+
+			if diff := cmp.Diff(apcu, tc.wantAPCu); diff != "" {
+				t.Errorf("apcu differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSystemHostResources(t *testing.T) {
+	tt := []struct {
+		inputFile   string
+		wantCPULoad []float64
+		wantMemory  MemoryInfo
+		wantSwap    MemoryInfo
+	}{
+		{
+			inputFile:   "info.json",
+			wantCPULoad: []float64{0.08, 0.05, 0.06},
+			wantMemory: MemoryInfo{
+				Available: true,
+				Total:     1986232 * 1024,
+				Free:      1285532 * 1024,
+			},
+			wantSwap: MemoryInfo{
+				Available: true,
+			},
+		},
+		{
+			inputFile:   "na-values.json",
+			wantCPULoad: []float64{0.08, 0.05, 0.06},
+			wantMemory:  MemoryInfo{},
+			wantSwap:    MemoryInfo{},
+		},
+		{
+			inputFile:   "unavailable-memory.json",
+			wantCPULoad: []float64{0.08, 0.05, 0.06},
+			wantMemory:  MemoryInfo{},
+			wantSwap:    MemoryInfo{},
+		},
+		{
+			inputFile:   "nc22.json",
+			wantCPULoad: []float64{0.8, 0.4, 0.3},
+			wantMemory: MemoryInfo{
+				Available: true,
+				Total:     394078 * 1024,
+				Free:      184536 * 1024,
+			},
+			wantSwap: MemoryInfo{
+				Available: true,
+				Total:     52428 * 1024,
+				Free:      3960 * 1024,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.inputFile, func(t *testing.T) {
+			t.Parallel()
+
+			reader, err := os.Open("testdata/" + tc.inputFile)
+			if err != nil {
+				t.Fatalf("error opening test data: %s", err)
+			}
+			defer reader.Close()
+
+			info, err := ParseJSON(reader)
+			if err != nil {
+				t.Fatalf("got error %q", err)
+			}
+
+			system := info.Data.Nextcloud.System
+			if diff := cmp.Diff(system.CPULoad, tc.wantCPULoad); diff != "" {
+				t.Errorf("cpuload differs: -got+want\n%s", diff)
+			}
+
+			if diff := cmp.Diff(system.Memory, tc.wantMemory); diff != "" {
+				t.Errorf("memory differs: -got+want\n%s", diff)
+			}
+
+			if diff := cmp.Diff(system.Swap, tc.wantSwap); diff != "" {
+				t.Errorf("swap differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSystemUnmarshalXML(t *testing.T) {
+	input := `<system>
+  <version>21.0.3.1</version>
+  <enable_avatars>yes</enable_avatars>
+  <cpuload>
+    <element>0.08</element>
+    <element>0.05</element>
+    <element>0.06</element>
+  </cpuload>
+  <mem_total>1986232</mem_total>
+  <mem_free>1285532</mem_free>
+  <swap_total>N/A</swap_total>
+  <swap_free>N/A</swap_free>
+</system>`
+	wantSystem := System{
+		Version:       "21.0.3.1",
+		EnableAvatars: true,
+		CPULoad:       []float64{0.08, 0.05, 0.06},
+		Memory: MemoryInfo{
+			Available: true,
+			Total:     1986232 * 1024,
+			Free:      1285532 * 1024,
+		},
+	}
+
+	var system System
+	if err := xml.Unmarshal([]byte(input), &system); err != nil {
+		t.Fatalf("got error %q", err)
+	}
+
+	if diff := cmp.Diff(system, wantSystem); diff != "" {
+		t.Errorf("system differs: -got+want\n%s", diff)
+	}
+}
+
+func TestSharesUnmarshalJSON(t *testing.T) {
+	tt := []struct {
+		desc       string
+		input      string
+		wantShares Shares
+		wantErr    error
+	}{
+		{
+			desc: "permissions",
+			input: `{
+  "num_shares": 8,
+  "num_shares_link": 7,
+  "permissions_0_1": "3",
+  "permissions_3_1": "43",
+  "permissions_3_17": 27
+}`,
+			wantShares: Shares{
+				SharesTotal: 8,
+				SharesLink:  7,
+				Permissions: map[SharePermissionsKey]uint{
+					{ShareType: 0, Permissions: 1}:  3,
+					{ShareType: 3, Permissions: 1}:  43,
+					{ShareType: 3, Permissions: 17}: 27,
+				},
+			},
+		},
+		{
+			desc: "no permissions",
+			input: `{
+  "num_shares": 1,
+  "num_shares_user": 1
+}`,
+			wantShares: Shares{
+				SharesTotal: 1,
+				SharesUser:  1,
+			},
+		},
+		{
+			desc: "invalid key",
+			input: `{
+  "permissions_3": "1",
+  "permissions_0_1": "3"
+}`,
+			wantShares: Shares{
+				Permissions: map[SharePermissionsKey]uint{
+					{ShareType: 0, Permissions: 1}: 3,
+				},
+			},
+		},
+		{
+			desc: "unknown key",
+			input: `{
+  "permissions_link_read": 2,
+  "permissions_0_1": "3"
+}`,
+			wantShares: Shares{
+				Permissions: map[SharePermissionsKey]uint{
+					{ShareType: 0, Permissions: 1}: 3,
+				},
+			},
+		},
+		{
+			desc: "invalid value",
+			input: `{
+  "permissions_3_1": "many",
+  "permissions_0_1": "3"
+}`,
+			wantShares: Shares{
+				Permissions: map[SharePermissionsKey]uint{
+					{ShareType: 0, Permissions: 1}: 3,
+				},
+			},
+		},
+		{
+			desc:    "invalid count",
+			input:   `{"num_shares": "many"}`,
+			wantErr: errors.New("json: cannot unmarshal string into Go struct field rawShares.num_shares of type uint"),
+		},
+		{
+			desc: "null value",
+			input: `{
+  "num_shares": 3,
+  "permissions_0_1": null,
+  "permissions_3_1": 3
+}`,
+			wantShares: Shares{
+				SharesTotal: 3,
+				Permissions: map[SharePermissionsKey]uint{
+					{ShareType: 3, Permissions: 1}: 3,
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var shares Shares
+			err := json.Unmarshal([]byte(tc.input), &shares)
+
+			if !testutil.EqualErrorMessage(err, tc.wantErr) {
+				t.Errorf("got error %q, want %q", err, tc.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(shares, tc.wantShares); diff != "" {
+				t.Errorf("shares differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppsUnmarshalJSON(t *testing.T) {
+	tt := []struct {
+		desc     string
+		input    string
+		wantApps Apps
+	}{
+		{
+			desc: "updates",
+			input: `{
+  "num_installed": 42,
+  "num_updates_available": 2,
+  "app_updates": {
+    "calendar": "4.5.0",
+    "contacts": "5.3.0"
+  }
+}`,
+			wantApps: Apps{
+				Installed:        42,
+				AvailableUpdates: 2,
+				Updates: map[string]string{
+					"calendar": "4.5.0",
+					"contacts": "5.3.0",
+				},
+			},
+		},
+		{
+			desc: "no updates",
+			input: `{
+  "num_installed": 42,
+  "num_updates_available": 0,
+  "app_updates": []
+}`,
+			wantApps: Apps{
+				Installed: 42,
+			},
+		},
+		{
+			desc:     "skipped apps",
+			input:    `{}`,
+			wantApps: Apps{},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var apps Apps
+			if err := json.Unmarshal([]byte(tc.input), &apps); err != nil {
+				t.Fatalf("got error %q", err)
+			}
+
+			if diff := cmp.Diff(apps, tc.wantApps); diff != "" {
+				t.Errorf("apps differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package serverinfo
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Status contains the information provided by the unauthenticated status.php endpoint.
+type Status struct {
+	Installed       bool   `json:"installed"`
+	Maintenance     bool   `json:"maintenance"`
+	NeedsDBUpgrade  bool   `json:"needsDbUpgrade"`
+	Version         string `json:"version"`
+	VersionString   string `json:"versionstring"`
+	Edition         string `json:"edition"`
+	ProductName     string `json:"productname"`
+	ExtendedSupport bool   `json:"extendedSupport"`
+}
+
+// ParseStatus reads Status from a Reader in JSON format.
+func ParseStatus(r io.Reader) (*Status, error) {
+	var result Status
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
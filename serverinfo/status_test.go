@@ -0,0 +1,32 @@
+package serverinfo
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseStatus(t *testing.T) {
+	reader, err := os.Open("testdata/status.json")
+	if err != nil {
+		t.Fatalf("error opening test data: %s", err)
+	}
+	defer reader.Close()
+
+	wantStatus := &Status{
+		Installed:     true,
+		Version:       "31.0.5.1",
+		VersionString: "31.0.5",
+		ProductName:   "Nextcloud",
+	}
+
+	status, err := ParseStatus(reader)
+	if err != nil {
+		t.Fatalf("got error %q", err)
+	}
+
+	if diff := cmp.Diff(status, wantStatus); diff != "" {
+		t.Errorf("status differs: -got+want\n%s", diff)
+	}
+}
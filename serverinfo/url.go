@@ -2,13 +2,26 @@ package serverinfo
 
 import (
 	"fmt"
+	"net/url"
 )
 
 const (
-	infoPathFormat = "%s/ocs/v2.php/apps/serverinfo/api/v1/info?format=json&skipApps=%v&skipUpdate=%v"
+	infoPathFormat    = "%s/ocs/v2.php/apps/serverinfo/api/v1/info?format=json&skipApps=%v&skipUpdate=%v"
+	appInfoPathFormat = "%s/ocs/v1.php/cloud/apps/%s?format=json"
+	statusPath        = "/status.php"
 )
 
 // InfoURL constructs the URL of the info endpoint from the server base URL and optional parameters.
 func InfoURL(serverURL string, skipApps bool, skipUpdate bool) string {
 	return fmt.Sprintf(infoPathFormat, serverURL, skipApps, skipUpdate)
 }
+
+// AppInfoURL constructs the URL of the OCS endpoint providing information about an installed app.
+func AppInfoURL(serverURL, appID string) string {
+	return fmt.Sprintf(appInfoPathFormat, serverURL, url.PathEscape(appID))
+}
+
+// StatusURL constructs the URL of the status endpoint, which can be accessed without authentication.
+func StatusURL(serverURL string) string {
+	return serverURL + statusPath
+}
@@ -63,3 +63,19 @@ func TestInfoURL(t *testing.T) {
 		})
 	}
 }
+
+func TestAppInfoURL(t *testing.T) {
+	url := AppInfoURL("https://nextcloud.example.com", "calendar")
+	wantURL := "https://nextcloud.example.com/ocs/v1.php/cloud/apps/calendar?format=json"
+	if url != wantURL {
+		t.Errorf("got url %q, want %q", url, wantURL)
+	}
+}
+
+func TestStatusURL(t *testing.T) {
+	url := StatusURL("https://nextcloud.example.com")
+	wantURL := "https://nextcloud.example.com/status.php"
+	if url != wantURL {
+		t.Errorf("got url %q, want %q", url, wantURL)
+	}
+}